@@ -19,8 +19,13 @@ var CLI struct {
 		Suite string `arg:"" name:"suite name" help:"The test suite to run. These are located in ./test-suites."`
 	} `cmd:"" help:"Run a specified test suite"`
 	Plan struct {
-		Name string `arg:"" optional:"" name:"name" help:"The name of the test suite to be generated."`
-		Path string `arg:"" optional:"" type:"existingfile" name:"path" help:"Location of the planner configuration."`
+		Name        string `arg:"" optional:"" name:"name" help:"The name of the test suite to be generated."`
+		Path        string `arg:"" optional:"" type:"existingfile" name:"path" help:"Location of the planner configuration."`
+		FromNetwork string `optional:"" type:"existingfile" name:"from-network" help:"Plan against the participants of an existing network config instead of generating a topology."`
+		OutputDir   string `optional:"" type:"existingdir" name:"output-dir" help:"Project directory to write the plan into. Defaults to the current working directory."`
+		Force       bool   `optional:"" default:"false" name:"force" help:"Overwrite existing plans with the same name."`
+		Split       int    `optional:"" default:"1" name:"split" help:"Split the generated tests across N test suites that share one network config."`
+		Estimate    bool   `optional:"" default:"false" name:"estimate" help:"Print a runtime and resource estimate for the plan instead of writing it."`
 	} `cmd:"" help:"Construct an attacknet suite for a client"`
 	// Explore struct{} `cmd:"" help:"Run in exploration mode"`
 }
@@ -64,7 +69,18 @@ func main() {
 			log.Fatal(err)
 			os.Exit(1)
 		}
-		err = plan.BuildPlan(CLI.Plan.Name, config)
+		opts := plan.OutputOptions{
+			Dir:   CLI.Plan.OutputDir,
+			Force: CLI.Plan.Force,
+			Split: CLI.Plan.Split,
+
+			EstimateOnly: CLI.Plan.Estimate,
+		}
+		if CLI.Plan.FromNetwork != "" {
+			err = plan.BuildPlanFromNetwork(CLI.Plan.Name, config, CLI.Plan.FromNetwork, opts)
+		} else {
+			err = plan.BuildPlan(CLI.Plan.Name, config, opts)
+		}
 		if err != nil {
 			log.Fatal(err)
 			os.Exit(1)
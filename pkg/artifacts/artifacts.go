@@ -3,6 +3,7 @@ package artifacts
 import (
 	chaosMesh "attacknet/cmd/pkg/chaos-mesh"
 	"attacknet/cmd/pkg/health"
+	"attacknet/cmd/pkg/health/ethereum"
 	healthTypes "attacknet/cmd/pkg/health/types"
 	"attacknet/cmd/pkg/types"
 	"errors"
@@ -16,16 +17,30 @@ import (
 )
 
 type TestArtifact struct {
-	TestDescription    string                         `yaml:"test_description"`
-	ContainersTargeted []string                       `yaml:"fault_injection_targets"`
-	TestPassed         bool                           `yaml:"test_passed"`
-	HealthResult       *healthTypes.HealthCheckResult `yaml:"health_check_results"`
+	TestDescription       string                         `yaml:"test_description"`
+	ContainersTargeted    []string                       `yaml:"fault_injection_targets"`
+	TestPassed            bool                           `yaml:"test_passed"`
+	HealthResult          *healthTypes.HealthCheckResult `yaml:"health_check_results"`
+	ValidatorKeysTargeted *int                           `yaml:"validator_keys_targeted,omitempty"`
+	ValidatorKeysFailing  *int                           `yaml:"validator_keys_failing_checks,omitempty"`
+	DashboardSnapshots    []*DashboardSnapshot           `yaml:"dashboard_snapshots,omitempty"`
 }
 
+// DashboardSnapshot is a grafana dashboard captured for the time window of a failed test.
+type DashboardSnapshot struct {
+	Dashboard    string `yaml:"dashboard"`
+	DashboardUid string `yaml:"dashboard_uid"`
+	SnapshotFile string `yaml:"snapshot_file,omitempty"` // the dashboard and its panel data, which outlive the enclave
+	RenderedFile string `yaml:"rendered_file,omitempty"` // set when grafana has the image renderer installed
+}
+
+// BuildTestArtifact summarizes a test. validatorKeys maps node indices to the validator keys they hold and may be nil
+// if the network config couldn't be parsed.
 func BuildTestArtifact(
 	healthResults *healthTypes.HealthCheckResult,
 	podsUnderTest []*chaosMesh.PodUnderTest,
 	test types.SuiteTest,
+	validatorKeys map[int]int,
 ) *TestArtifact {
 
 	var containersTargeted []string
@@ -35,20 +50,47 @@ func BuildTestArtifact(
 
 	testPassed := health.AllChecksPassed(healthResults)
 
-	return &TestArtifact{
-		test.TestName,
-		containersTargeted,
-		testPassed,
-		healthResults,
+	artifact := &TestArtifact{
+		TestDescription:    test.TestName,
+		ContainersTargeted: containersTargeted,
+		TestPassed:         testPassed,
+		HealthResult:       healthResults,
 	}
+
+	if validatorKeys != nil {
+		targeted := countValidatorKeys(containersTargeted, validatorKeys)
+		var failingPods []string
+		for pod := range health.FailingPods(healthResults) {
+			failingPods = append(failingPods, pod)
+		}
+		failing := countValidatorKeys(failingPods, validatorKeys)
+		artifact.ValidatorKeysTargeted = &targeted
+		artifact.ValidatorKeysFailing = &failing
+	}
+
+	return artifact
 }
 
-func SerializeTestArtifacts(artifacts []*TestArtifact) error {
-	artifactFilename := fmt.Sprintf("results-%d.yaml", time.Now().UnixMilli())
+// countValidatorKeys sums the validator keys of the nodes the pods belong to. Each node is only counted once, even
+// if several of its clients are in the list.
+func countValidatorKeys(podNames []string, validatorKeys map[int]int) int {
+	nodesCounted := make(map[int]bool)
+	keys := 0
+	for _, podName := range podNames {
+		index, ok := ethereum.NodeIndexFromPodName(podName)
+		if !ok || nodesCounted[index] {
+			continue
+		}
+		nodesCounted[index] = true
+		keys += validatorKeys[index]
+	}
+	return keys
+}
 
+func artifactDirectory() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return err
+		return "", err
 	}
 	path := path2.Join(cwd, "artifacts")
 
@@ -58,6 +100,31 @@ func SerializeTestArtifacts(artifacts []*TestArtifact) error {
 			log.Println(err)
 		}
 	}
+	return path, nil
+}
+
+// SnapshotDirectory returns the directory saved and rendered dashboards of the run that started at runStart are saved to,
+// creating it if needed.
+func SnapshotDirectory(runStart time.Time) (string, error) {
+	path, err := artifactDirectory()
+	if err != nil {
+		return "", err
+	}
+	path = path2.Join(path, fmt.Sprintf("grafana-%d", runStart.UnixMilli()))
+	err = os.MkdirAll(path, os.ModePerm)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "could not create snapshot directory %s", path)
+	}
+	return path, nil
+}
+
+func SerializeTestArtifacts(artifacts []*TestArtifact) error {
+	artifactFilename := fmt.Sprintf("results-%d.yaml", time.Now().UnixMilli())
+
+	path, err := artifactDirectory()
+	if err != nil {
+		return err
+	}
 
 	artifactPath := path2.Join(path, artifactFilename)
 	bs, err := yaml.Marshal(artifacts)
@@ -0,0 +1,26 @@
+package artifacts
+
+import "testing"
+
+func TestCountValidatorKeys(t *testing.T) {
+	validatorKeys := map[int]int{1: 32, 2: 0, 3: 128}
+
+	type testCase struct {
+		name     string
+		pods     []string
+		expected int
+	}
+	testCases := []testCase{
+		{name: "no pods", pods: nil, expected: 0},
+		{name: "one node", pods: []string{"cl-1-lighthouse-geth"}, expected: 32},
+		{name: "node counted once across clients", pods: []string{"el-1-geth-lighthouse", "cl-1-lighthouse-geth", "vc-1-geth-lighthouse"}, expected: 32},
+		{name: "node without keys", pods: []string{"el-2-reth-teku"}, expected: 0},
+		{name: "several nodes", pods: []string{"el-1-geth-lighthouse", "cl-3-prysm-nethermind"}, expected: 160},
+		{name: "pods outside the network", pods: []string{"grafana", "prometheus-server", "cl-9-teku-besu"}, expected: 0},
+	}
+	for _, test := range testCases {
+		if keys := countValidatorKeys(test.pods, validatorKeys); keys != test.expected {
+			t.Errorf("%s: expected %d keys, got %d", test.name, test.expected, keys)
+		}
+	}
+}
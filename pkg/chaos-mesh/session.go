@@ -301,6 +301,33 @@ func (f *FaultSession) getDuration(ctx context.Context) (*time.Duration, error)
 	return &duration, err
 }
 
+// Description names the kind and action of the fault, e.g. NetworkChaos/loss.
+func (f *FaultSession) Description() string {
+	return fmt.Sprintf("%s/%s", f.faultType, f.faultAction)
+}
+
+// PodNames returns the names of the pods the fault was injected into.
+func (f *FaultSession) PodNames() []string {
+	names := make([]string, len(f.PodsUnderTest))
+	for i, pod := range f.PodsUnderTest {
+		names[i] = pod.Name
+	}
+	return names
+}
+
+// Remove deletes the fault resource. chaos-mesh recovers the targets of faults that are still running.
+func (f *FaultSession) Remove(ctx context.Context) error {
+	resource, err := f.getKubeFaultResource(ctx)
+	if err != nil {
+		return err
+	}
+	err = f.client.kubeApiClient.Delete(ctx, resource)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to delete fault %s", f.Name)
+	}
+	return nil
+}
+
 func buildPodsUnderTestSlice(ctx context.Context, client *ChaosClient, podNames []string, expectDeath bool) ([]*PodUnderTest, error) {
 	podsUnderTest := make([]*PodUnderTest, len(podNames))
 
@@ -1,31 +1,52 @@
 package pkg
 
 import (
+	"attacknet/cmd/pkg/artifacts"
+	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
 	"attacknet/cmd/pkg/kubernetes"
 	"attacknet/cmd/pkg/types"
 	"context"
+	"encoding/json"
 	"fmt"
 	grafanaSdk "github.com/grafana-tools/sdk"
 	"github.com/kurtosis-tech/stacktrace"
 	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 )
 
+const (
+	grafanaRequestTimeout = 10 * time.Second
+	// rendering a whole dashboard takes a while
+	grafanaRenderTimeout = 60 * time.Second
+	renderWidth          = 1600
+	renderHeight         = 2400
+	// prometheus rejects range queries with more than 11000 points per series
+	maxQueryPoints = 1000
+	minQueryStep   = 15 * time.Second
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
 // note: we may move the grafana logic to the health module if we move towards grafana-based health alerts
 type GrafanaTunnel struct {
 	Client                   *grafanaSdk.Client
-	portForwardStopCh        chan struct{}
+	endpoint                 *kubernetes.PodEndpoint
 	allowPostFaultInspection bool
 	cleanedUp                bool
 }
 
 func CreateGrafanaClient(ctx context.Context, kubeClient *kubernetes.KubeClient, config types.AttacknetConfig) (*GrafanaTunnel, error) {
 	podName := config.GrafanaPodName
-	exists, err := kubeClient.PodExists(ctx, podName)
+	pod, err := kubeClient.PodByName(ctx, podName)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "error while determining whether pod exists %s", podName)
-	}
-	if !exists {
-		return nil, stacktrace.NewError("unable to locate grafana pod %s", podName)
+		return nil, stacktrace.Propagate(err, "unable to locate grafana pod %s", podName)
 	}
 
 	var port uint16
@@ -34,26 +55,342 @@ func CreateGrafanaClient(ctx context.Context, kubeClient *kubernetes.KubeClient,
 		return nil, stacktrace.Propagate(err, "unable to decode port number %s", config.GrafanaPodPort)
 	}
 
-	stopCh, err := kubeClient.StartPortForwarding(podName, int(port), int(port), true)
+	endpoint, err := kubeClient.OpenEndpoint(pod, podName, int(port), config.AccessMode)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "unable to start port forwarder")
+		return nil, stacktrace.Propagate(err, "unable to open a connection to grafana")
 	}
 
-	client, err := grafanaSdk.NewClient("http://localhost:3000", "", grafanaSdk.DefaultHTTPClient)
+	client, err := grafanaSdk.NewClient("http://"+endpoint.Address, "", grafanaSdk.DefaultHTTPClient)
 	if err != nil {
+		endpoint.Close()
 		return nil, stacktrace.Propagate(err, "unable to create Grafana client")
 	}
 
-	return &GrafanaTunnel{client, stopCh, config.AllowPostFaultInspection, false}, nil
+	return &GrafanaTunnel{client, endpoint, config.AllowPostFaultInspection, false}, nil
 }
 
 func (t *GrafanaTunnel) Cleanup(skipInspection bool) {
+	if t == nil {
+		return
+	}
 	if !t.cleanedUp {
 		if t.allowPostFaultInspection && !skipInspection {
-			log.Info("Press enter to terminate the port-forward connection.")
-			_, _ = fmt.Scanln()
+			if stdinIsTerminal() {
+				log.Infof("Grafana is available at http://%s. Press enter to terminate the connection.", t.endpoint.Address)
+				_, _ = fmt.Scanln()
+			} else {
+				// nobody can press enter in CI. failed tests have dashboard snapshots in their artifacts instead
+				log.Info("Skipping post-fault inspection since stdin isn't a terminal")
+			}
 		}
-		close(t.portForwardStopCh)
+		t.endpoint.Close()
 		t.cleanedUp = true
 	}
 }
+
+// Annotate marks the current time on every grafana dashboard. Annotations are tagged with the test name and pods so
+// they can be filtered per test. Failures are only logged, since annotations don't affect the outcome of a test. A nil
+// tunnel annotates nothing, so attacknet keeps working when grafana isn't deployed.
+func (t *GrafanaTunnel) Annotate(ctx context.Context, event, testName string, pods []string, text string) {
+	if t == nil {
+		return
+	}
+	tags := append([]string{"attacknet", event, testName}, pods...)
+	if len(pods) > 0 {
+		text = fmt.Sprintf("%s. Pods: %s", text, strings.Join(pods, ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, grafanaRequestTimeout)
+	defer cancel()
+	_, err := t.Client.CreateAnnotation(ctx, grafanaSdk.CreateAnnotationRequest{
+		Time: time.Now().UnixMilli(),
+		Tags: tags,
+		Text: fmt.Sprintf("[%s] %s", testName, text),
+	})
+	if err != nil {
+		log.Warnf("Unable to create grafana annotation for %s: %s", event, err)
+	}
+}
+
+func (t *GrafanaTunnel) FaultInjected(ctx context.Context, testName string, session *chaos_mesh.FaultSession) {
+	t.Annotate(ctx, "fault-injected", testName, session.PodNames(), fmt.Sprintf("Injected %s", session.Description()))
+}
+
+func (t *GrafanaTunnel) FaultRecovered(ctx context.Context, testName string, session *chaos_mesh.FaultSession) {
+	t.Annotate(ctx, "fault-recovered", testName, session.PodNames(), fmt.Sprintf("%s recovered", session.Description()))
+}
+
+func (t *GrafanaTunnel) FaultRemoved(ctx context.Context, testName string, session *chaos_mesh.FaultSession) {
+	t.Annotate(ctx, "fault-removed", testName, session.PodNames(), fmt.Sprintf("Removed %s", session.Description()))
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// SnapshotDashboards captures every dashboard for the time window of a test. Each dashboard is saved to dir as JSON
+// with the prometheus data of its panels and, if grafana can render images, as a PNG. Files that can't be saved are
+// left out of the result.
+func (t *GrafanaTunnel) SnapshotDashboards(ctx context.Context, testName string, from, to time.Time, dir string) ([]*artifacts.DashboardSnapshot, error) {
+	if t == nil {
+		return nil, nil
+	}
+	searchCtx, cancel := context.WithTimeout(ctx, grafanaRequestTimeout)
+	defer cancel()
+	boards, err := t.Client.SearchDashboards(searchCtx, "", false)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to list grafana dashboards")
+	}
+
+	window := url.Values{
+		"from": {fmt.Sprint(from.UnixMilli())},
+		"to":   {fmt.Sprint(to.UnixMilli())},
+	}
+	var snapshots []*artifacts.DashboardSnapshot
+	for _, found := range boards {
+		snapshot := &artifacts.DashboardSnapshot{
+			Dashboard:    found.Title,
+			DashboardUid: found.UID,
+		}
+
+		filename := fmt.Sprintf("%s-%s", unsafeFilenameChars.ReplaceAllString(testName, "-"), found.UID)
+		snapshotFile := filepath.Join(dir, filename+".json")
+		err = t.saveDashboard(ctx, found.UID, from, to, snapshotFile)
+		if err != nil {
+			log.Warnf("Unable to save grafana dashboard %s: %s", found.Title, err)
+		} else {
+			snapshot.SnapshotFile = snapshotFile
+		}
+
+		renderedFile := filepath.Join(dir, filename+".png")
+		err = t.renderDashboard(ctx, found.UID, window, renderedFile)
+		if err != nil {
+			log.Debugf("Unable to render grafana dashboard %s: %s", found.Title, err)
+		} else {
+			snapshot.RenderedFile = renderedFile
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	log.Infof("Captured %d grafana dashboards for test '%s'", len(snapshots), testName)
+	return snapshots, nil
+}
+
+// dashboardExport is a dashboard saved with the prometheus data of its panels, so it can be inspected once the enclave
+// is gone.
+type dashboardExport struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Dashboard json.RawMessage `json:"dashboard"`
+	Queries   []*panelQuery   `json:"queries"`
+}
+
+type panelQuery struct {
+	Panel  string          `json:"panel"`
+	RefId  string          `json:"ref_id"`
+	Expr   string          `json:"expr"`
+	Result json.RawMessage `json:"result,omitempty"` // the data of the prometheus query_range response
+	Error  string          `json:"error,omitempty"`
+}
+
+type exportedPanel struct {
+	Title   string `json:"title"`
+	Targets []struct {
+		RefId string `json:"refId"`
+		Expr  string `json:"expr"`
+	} `json:"targets"`
+	Panels []exportedPanel `json:"panels"` // panels nested in collapsed rows
+}
+
+// saveDashboard writes the dashboard JSON and the result of every prometheus query of its panels over the time window
+// to path. Queries that fail are saved with their error.
+func (t *GrafanaTunnel) saveDashboard(ctx context.Context, uid string, from, to time.Time, path string) error {
+	getCtx, cancel := context.WithTimeout(ctx, grafanaRequestTimeout)
+	defer cancel()
+	board, _, err := t.Client.GetRawDashboardByUID(getCtx, uid)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to fetch dashboard %s", uid)
+	}
+	var parsed struct {
+		Panels []exportedPanel `json:"panels"`
+	}
+	err = json.Unmarshal(board, &parsed)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to parse dashboard %s", uid)
+	}
+
+	export := &dashboardExport{From: from, To: to, Dashboard: board}
+	datasourceId, err := t.prometheusDatasource(ctx)
+	if err != nil {
+		return err
+	}
+	step := queryStep(from, to)
+	var addQueries func(panels []exportedPanel)
+	addQueries = func(panels []exportedPanel) {
+		for _, panel := range panels {
+			for _, target := range panel.Targets {
+				if target.Expr == "" {
+					continue
+				}
+				query := &panelQuery{Panel: panel.Title, RefId: target.RefId, Expr: target.Expr}
+				result, err := t.queryRange(ctx, datasourceId, expandQueryVariables(target.Expr, step, to.Sub(from)), from, to, step)
+				if err != nil {
+					query.Error = err.Error()
+				} else {
+					query.Result = result
+				}
+				export.Queries = append(export.Queries, query)
+			}
+			addQueries(panel.Panels)
+		}
+	}
+	addQueries(parsed.Panels)
+
+	bs, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to marshal dashboard %s", uid)
+	}
+	err = os.WriteFile(path, bs, 0600)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to write dashboard to %s", path)
+	}
+	return nil
+}
+
+// prometheusDatasource returns the id of the prometheus datasource grafana proxies queries to, preferring the default.
+func (t *GrafanaTunnel) prometheusDatasource(ctx context.Context) (uint, error) {
+	ctx, cancel := context.WithTimeout(ctx, grafanaRequestTimeout)
+	defer cancel()
+	datasources, err := t.Client.GetAllDatasources(ctx)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "unable to list grafana datasources")
+	}
+	var found *grafanaSdk.Datasource
+	for i, datasource := range datasources {
+		if datasource.Type != "prometheus" {
+			continue
+		}
+		if found == nil || datasource.IsDefault {
+			found = &datasources[i]
+		}
+	}
+	if found == nil {
+		return 0, stacktrace.NewError("grafana has no prometheus datasource")
+	}
+	return found.ID, nil
+}
+
+func (t *GrafanaTunnel) queryRange(ctx context.Context, datasourceId uint, expr string, from, to time.Time, step time.Duration) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, grafanaRequestTimeout)
+	defer cancel()
+	params := url.Values{
+		"query": {expr},
+		"start": {fmt.Sprint(from.Unix())},
+		"end":   {fmt.Sprint(to.Unix())},
+		"step":  {fmt.Sprint(int(step.Seconds()))},
+	}
+	queryUrl := fmt.Sprintf("http://%s/api/datasources/proxy/%d/api/v1/query_range?%s", t.endpoint.Address, datasourceId, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryUrl, nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to build query request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "query request failed")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+		Error  string          `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to decode query response with status %d", resp.StatusCode)
+	}
+	if result.Status != "success" {
+		return nil, stacktrace.NewError("prometheus returned status %d: %s", resp.StatusCode, result.Error)
+	}
+	return result.Data, nil
+}
+
+// queryStep keeps the number of points per series in the range prometheus allows.
+func queryStep(from, to time.Time) time.Duration {
+	step := (to.Sub(from) / maxQueryPoints).Truncate(time.Second)
+	if step < minQueryStep {
+		return minQueryStep
+	}
+	return step
+}
+
+var (
+	builtinVariable = regexp.MustCompile(`\$\{?__(rate_interval|interval|range)(_ms|_s)?\b\}?`)
+	matcherVariable = regexp.MustCompile(`=~?"\$\{?\w+(:\w+)?\}?"`)
+)
+
+// expandQueryVariables replaces the grafana variables of a query. Built-in intervals are derived from the step and the
+// window, and dashboard variables used in label matchers select every value.
+func expandQueryVariables(expr string, step, window time.Duration) string {
+	expr = builtinVariable.ReplaceAllStringFunc(expr, func(variable string) string {
+		match := builtinVariable.FindStringSubmatch(variable)
+		value := step
+		switch match[1] {
+		case "rate_interval":
+			// grafana uses at least 4 scrape intervals so rate() always has enough samples
+			value = 4 * step
+		case "range":
+			value = window
+		}
+		// the suffixed variants are plain numbers rather than durations
+		switch match[2] {
+		case "_ms":
+			return fmt.Sprint(value.Milliseconds())
+		case "_s":
+			return fmt.Sprint(int(value.Seconds()))
+		}
+		return fmt.Sprintf("%ds", int(value.Seconds()))
+	})
+	return matcherVariable.ReplaceAllString(expr, `=~".*"`)
+}
+
+// renderDashboard saves a PNG of the dashboard. This requires the grafana-image-renderer plugin, which grafana
+// doesn't ship with.
+func (t *GrafanaTunnel) renderDashboard(ctx context.Context, uid string, window url.Values, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, grafanaRenderTimeout)
+	defer cancel()
+	params := url.Values{
+		"width":  {fmt.Sprint(renderWidth)},
+		"height": {fmt.Sprint(renderHeight)},
+		"kiosk":  {"true"},
+	}
+	for k, v := range window {
+		params[k] = v
+	}
+	renderUrl := fmt.Sprintf("http://%s/render/d/%s?%s", t.endpoint.Address, uid, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, renderUrl, nil)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to build render request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return stacktrace.Propagate(err, "render request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+		return stacktrace.NewError("grafana returned status %d (%s). is the image renderer installed?", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	image, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to read rendered dashboard")
+	}
+	err = os.WriteFile(path, image, 0600)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to write rendered dashboard to %s", path)
+	}
+	return nil
+}
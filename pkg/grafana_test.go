@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryStep(t *testing.T) {
+	type testCase struct {
+		name     string
+		window   time.Duration
+		expected time.Duration
+	}
+	testCases := []testCase{
+		{name: "short window uses the minimum step", window: 10 * time.Minute, expected: 15 * time.Second},
+		{name: "window at the minimum step", window: 15000 * time.Second, expected: 15 * time.Second},
+		{name: "long window", window: 10 * time.Hour, expected: 36 * time.Second},
+		{name: "step is truncated to seconds", window: 10*time.Hour + 500*time.Second, expected: 36 * time.Second},
+	}
+	from := time.Unix(1700000000, 0)
+	for _, test := range testCases {
+		step := queryStep(from, from.Add(test.window))
+		if step != test.expected {
+			t.Errorf("%s: expected step %s, got %s", test.name, test.expected, step)
+		}
+	}
+}
+
+func TestExpandQueryVariables(t *testing.T) {
+	type testCase struct {
+		name     string
+		expr     string
+		expected string
+	}
+	testCases := []testCase{
+		{name: "no variables", expr: `up{job="geth"}`, expected: `up{job="geth"}`},
+		{name: "interval", expr: `rate(x[$__interval])`, expected: `rate(x[15s])`},
+		{name: "braced interval", expr: `rate(x[${__interval}])`, expected: `rate(x[15s])`},
+		{name: "interval ms", expr: `x / $__interval_ms`, expected: `x / 15000`},
+		{name: "rate interval", expr: `rate(x[$__rate_interval])`, expected: `rate(x[60s])`},
+		{name: "rate interval ms", expr: `x / ${__rate_interval_ms}`, expected: `x / 60000`},
+		{name: "range", expr: `increase(x[$__range])`, expected: `increase(x[600s])`},
+		{name: "braced range", expr: `increase(x[${__range}])`, expected: `increase(x[600s])`},
+		{name: "range seconds", expr: `x / $__range_s`, expected: `x / 600`},
+		{name: "braced range seconds", expr: `x / ${__range_s}`, expected: `x / 600`},
+		{name: "range ms", expr: `x / $__range_ms`, expected: `x / 600000`},
+		{name: "unknown suffix is left alone", expr: `x / $__range_foo`, expected: `x / $__range_foo`},
+		{name: "matcher variable", expr: `up{instance="$instance"}`, expected: `up{instance=~".*"}`},
+		{name: "regex matcher variable", expr: `up{job=~"${job:regex}"}`, expected: `up{job=~".*"}`},
+		{
+			name:     "mixed",
+			expr:     `sum(increase(x{job=~"$job"}[$__range])) / $__range_s`,
+			expected: `sum(increase(x{job=~".*"}[600s])) / 600`,
+		},
+	}
+	for _, test := range testCases {
+		expanded := expandQueryVariables(test.expr, 15*time.Second, 10*time.Minute)
+		if expanded != test.expected {
+			t.Errorf("%s: expected '%s', got '%s'", test.name, test.expected, expanded)
+		}
+	}
+}
@@ -13,23 +13,30 @@ import (
 )
 
 type CheckOrchestrator struct {
-	checkerImpl types.GenericNetworkChecker
-	gracePeriod *time.Duration
+	checkerImpl      types.GenericNetworkChecker
+	gracePeriod      *time.Duration
+	prometheus       *PrometheusClient
+	prometheusChecks []confTypes.PrometheusCheck
 }
 
-func BuildHealthChecker(kubeClient *kubernetes.KubeClient, podsUnderTest []*chaos_mesh.PodUnderTest, healthCheckConfig confTypes.HealthCheckConfig) (*CheckOrchestrator, error) {
+func BuildHealthChecker(kubeClient *kubernetes.KubeClient, rpcPool *ethereum.RpcPool, prometheus *PrometheusClient, podsUnderTest []*chaos_mesh.PodUnderTest, healthCheckConfig confTypes.HealthCheckConfig, clientSchema confTypes.ClientSchema, validatorKeys map[int]int) (*CheckOrchestrator, error) {
 	networkType := "ethereum"
 	var checkerImpl types.GenericNetworkChecker
 
 	switch networkType {
 	case "ethereum":
-		a := ethereum.CreateEthNetworkChecker(kubeClient, podsUnderTest)
+		a := ethereum.CreateEthNetworkChecker(kubeClient, rpcPool, podsUnderTest, clientSchema, healthCheckConfig, validatorKeys)
 		checkerImpl = a
 	default:
 		log.Errorf("unknown network type: %s", networkType)
 		return nil, stacktrace.NewError("unknown network type: %s", networkType)
 	}
-	return &CheckOrchestrator{checkerImpl: checkerImpl, gracePeriod: healthCheckConfig.GracePeriod}, nil
+	return &CheckOrchestrator{
+		checkerImpl:      checkerImpl,
+		gracePeriod:      healthCheckConfig.GracePeriod,
+		prometheus:       prometheus,
+		prometheusChecks: healthCheckConfig.PrometheusChecks,
+	}, nil
 }
 
 func (hc *CheckOrchestrator) RunChecks(ctx context.Context) (*types.HealthCheckResult, error) {
@@ -43,6 +50,9 @@ func (hc *CheckOrchestrator) RunChecks(ctx context.Context) (*types.HealthCheckR
 		if err != nil {
 			return nil, err
 		}
+		if len(hc.prometheusChecks) > 0 {
+			results.PrometheusResults = hc.prometheus.RunChecks(ctx, hc.prometheusChecks)
+		}
 		lastHealthCheckResult = results
 		if AllChecksPassed(results) {
 			timeToPass := time.Since(start).Seconds()
@@ -62,6 +72,16 @@ func (hc *CheckOrchestrator) RunChecks(ctx context.Context) (*types.HealthCheckR
 }
 
 func AllChecksPassed(checks *types.HealthCheckResult) bool {
+	for _, result := range []*types.BlockConsensusArtifact{
+		checks.LatestElBlockResult,
+		checks.FinalizedElBlockResult,
+		checks.LatestClBlockResult,
+		checks.FinalizedClBlockResult,
+	} {
+		if result.NoMajority {
+			return false
+		}
+	}
 	if len(checks.LatestElBlockResult.FailingClientsReportedBlock) > 0 {
 		return false
 	}
@@ -86,6 +106,80 @@ func AllChecksPassed(checks *types.HealthCheckResult) bool {
 	if len(checks.FinalizedClBlockResult.FailingClientsReportedHash) > 0 {
 		return false
 	}
+	for _, nodeStatus := range []*types.NodeStatusResult{checks.ElNodeStatusResult, checks.ClNodeStatusResult} {
+		if nodeStatus != nil && len(nodeStatus.FailingClients) > 0 {
+			return false
+		}
+	}
+	if checks.FinalityResult != nil {
+		if checks.FinalityResult.FinalityLagExceeded || len(checks.FinalityResult.FailingClientsReportedCheckpoints) > 0 {
+			return false
+		}
+	}
+	if checks.ValidatorResult != nil && !checks.ValidatorResult.Passed {
+		return false
+	}
+	if checks.PodStatusResult != nil {
+		for _, issue := range checks.PodStatusResult.UnexpectedIssues {
+			if issue.Bystander {
+				return false
+			}
+		}
+	}
+	for _, result := range checks.PrometheusResults {
+		if !result.Passed {
+			return false
+		}
+	}
 
 	return true
 }
+
+// FailingPods returns the names of every pod failing at least one health check.
+func FailingPods(checks *types.HealthCheckResult) map[string]bool {
+	failing := make(map[string]bool)
+	artifacts := []*types.BlockConsensusArtifact{
+		checks.LatestElBlockResult,
+		checks.FinalizedElBlockResult,
+		checks.LatestClBlockResult,
+		checks.FinalizedClBlockResult,
+	}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		for pod := range artifact.FailingClientsReportedBlock {
+			failing[pod] = true
+		}
+		for pod := range artifact.FailingClientsReportedHash {
+			failing[pod] = true
+		}
+		// without a majority, there's no telling which side is wrong
+		for _, partition := range artifact.Partitions {
+			for _, pod := range partition.Clients {
+				failing[pod] = true
+			}
+		}
+	}
+	for _, nodeStatus := range []*types.NodeStatusResult{checks.ElNodeStatusResult, checks.ClNodeStatusResult} {
+		if nodeStatus == nil {
+			continue
+		}
+		for pod := range nodeStatus.FailingClients {
+			failing[pod] = true
+		}
+	}
+	if checks.FinalityResult != nil {
+		for pod := range checks.FinalityResult.FailingClientsReportedCheckpoints {
+			failing[pod] = true
+		}
+	}
+	if checks.PodStatusResult != nil {
+		for _, issue := range checks.PodStatusResult.UnexpectedIssues {
+			if issue.Bystander {
+				failing[issue.Pod] = true
+			}
+		}
+	}
+	return failing
+}
@@ -3,156 +3,200 @@ package ethereum
 import (
 	"attacknet/cmd/pkg/health/types"
 	"attacknet/cmd/pkg/kubernetes"
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"fmt"
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/http"
 	"github.com/kurtosis-tech/stacktrace"
 	"github.com/rs/zerolog"
 	log "github.com/sirupsen/logrus"
-	"strings"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
+type beaconApiClient interface {
+	eth2client.BeaconBlockHeadersProvider
+	eth2client.NodeSyncingProvider
+	eth2client.FinalityProvider
+	eth2client.SpecProvider
+	eth2client.ProposerDutiesProvider
+}
+
 type BeaconClientRpc struct {
-	session *kubernetes.PortForwardsSession
-	client  eth2client.BeaconBlockHeadersProvider
+	endpoint *kubernetes.PodEndpoint
+	client   beaconApiClient
+	// used for endpoints go-eth2-client doesn't support
+	address     string
+	httpClient  *nethttp.Client
+	callTimeout time.Duration
+	stale       atomic.Bool // set when a call failed in a way that needs a new connection
 }
 
 func (e *EthNetworkChecker) getBeaconClientConsensus(ctx context.Context, clients []*BeaconClientRpc, blockType string, maxAttempts int) (*types.BlockConsensusTestResult, error) {
-	forkChoice, err := getBeaconNetworkConsensus(ctx, clients, blockType)
+	forkChoice, err := e.getBeaconNetworkConsensus(ctx, clients, blockType)
 	if err != nil {
 		return nil, err
 	}
 	// determine whether the nodes are in consensus
-	consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash := determineForkConsensus(forkChoice)
-	if len(wrongBlockNum) > 0 {
-		if maxAttempts > 0 {
-			log.Debugf("Nodes not at consensus for %s block. Waiting and re-trying in case we're on block propagation boundary. Attempts left: %d", blockType, maxAttempts-1)
-			time.Sleep(1 * time.Second)
-			return e.getBeaconClientConsensus(ctx, clients, blockType, maxAttempts-1)
-		} else {
-			reportConsensusDataToLogger(blockType, consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash)
-		}
+	consensus := determineForkConsensus(forkChoice)
+	if !consensus.inConsensus() && maxAttempts > 0 {
+		log.Debugf("Nodes not at consensus for %s block. Waiting and re-trying in case we're on block propagation boundary. Attempts left: %d", blockType, maxAttempts-1)
+		time.Sleep(1 * time.Second)
+		return e.getBeaconClientConsensus(ctx, clients, blockType, maxAttempts-1)
 	}
 
-	blockNumWrong := make(map[string]uint64)
-	for _, node := range wrongBlockNum {
-		blockNumWrong[node.Pod.GetName()] = node.BlockNumber
+	var forkStatus map[string]*types.NodeForkStatus
+	if blockType == "head" {
+		readers := make(map[string]chainReader)
+		for _, client := range clients {
+			readers[client.endpoint.Pod.GetName()] = client
+		}
+		e.detectReorgs(ctx, readers, forkChoice)
+		if !consensus.inConsensus() && len(consensus.consensus) > 0 {
+			var failing []*ClientForkChoice
+			failing = append(failing, consensus.wrongBlockNum...)
+			failing = append(failing, consensus.wrongBlockHash...)
+			forkStatus = classifyForks(ctx, readers, consensus.consensus[0], failing)
+		}
 	}
 
-	blockHashWrong := make(map[string]string)
-
-	for _, node := range wrongBlockHash {
-		blockHashWrong[node.Pod.GetName()] = node.BlockHash
-	}
-	reportConsensusDataToLogger(blockType, consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash)
-	return &types.BlockConsensusTestResult{
-		ConsensusBlock:              (consensusBlockNum)[0].BlockNumber,
-		ConsensusHash:               consensusBlockHash[0].BlockHash,
-		FailingClientsReportedBlock: blockNumWrong,
-		FailingClientsReportedHash:  blockHashWrong,
-	}, nil
+	reportConsensusDataToLogger(blockType, consensus)
+	return consensus.toTestResult(forkStatus), nil
 }
 
-func (e *EthNetworkChecker) dialToBeaconClients(ctx context.Context) ([]*BeaconClientRpc, error) {
-	labelKey := "kurtosistech.com.custom/ethereum-package.client-type"
-	labelValue := "beacon"
-	podsToHealthCheck, err := getPodsToHealthCheck(
+func (e *EthNetworkChecker) dialToBeaconClients(ctx context.Context) ([]*BeaconClientRpc, []string, error) {
+	podsToHealthCheck, podsNotRestarted, err := e.getPodsToHealthCheck(
 		ctx,
-		e.kubeClient,
-		e.podsUnderTest,
-		e.podsUnderTestLookup,
-		labelKey,
-		labelValue)
-	if err != nil {
-		return nil, err
-	}
-
-	// todo: fix this when kurtosis pkg supports setting the port
-	var port4000Batch []kubernetes.KubePod
-	var port3500Batch []kubernetes.KubePod
-
-	for _, pod := range podsToHealthCheck {
-		if strings.Contains(pod.GetName(), "prysm") {
-			port3500Batch = append(port3500Batch, pod)
-		} else {
-			port4000Batch = append(port4000Batch, pod)
-		}
-	}
-
-	log.Debugf("Starting port forward sessions to %d pods", len(podsToHealthCheck))
-
-	portForwardSessions3500, err := e.kubeClient.StartMultiPortForwardToLabeledPods(
-		port3500Batch,
-		labelKey,
-		labelValue,
-		3500)
+		e.clientSchema.ClientTypeLabel,
+		e.clientSchema.BeaconLabelValue)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	portForwardSessions4000, err := e.kubeClient.StartMultiPortForwardToLabeledPods(
-		port4000Batch,
-		labelKey,
-		labelValue,
-		4000)
+	rpcClients, err := e.rpcPool.BeaconClients(ctx, podsToHealthCheck)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	portForwardSessions := append(portForwardSessions3500, portForwardSessions4000...)
-
-	// dial out to clients
-	rpcClients := make([]*BeaconClientRpc, len(portForwardSessions))
-	for i, s := range portForwardSessions {
-		client, err := dialBeaconRpcClient(ctx, s)
-		if err != nil {
-			return nil, err
-		}
-		rpcClients[i] = client
-	}
-	return rpcClients, nil
+	return rpcClients, podsNotRestarted, nil
 }
 
-func dialBeaconRpcClient(ctx context.Context, session *kubernetes.PortForwardsSession) (*BeaconClientRpc, error) {
+func dialBeaconRpcClient(ctx context.Context, endpoint *kubernetes.PodEndpoint, callTimeout time.Duration) (*BeaconClientRpc, error) {
 	// 3 attempts
 	retryCount := 8
+	address := "http://" + endpoint.Address
 	for i := 0; i <= retryCount; i++ {
 		httpClient, err := http.New(ctx,
-			http.WithAddress(fmt.Sprintf("http://localhost:%d", session.LocalPort)),
+			http.WithAddress(address),
 			http.WithLogLevel(zerolog.WarnLevel),
 		)
 		if err != nil {
 			if i == retryCount {
-				return nil, stacktrace.Propagate(err, "err while dialing RPC for %s", session.Pod.GetName())
+				return nil, stacktrace.Propagate(err, "err while dialing RPC for %s", endpoint.Pod.GetName())
 			} else {
 				time.Sleep(1 * time.Second)
 				continue
 			}
 
 		}
-		provider, isProvider := httpClient.(eth2client.BeaconBlockHeadersProvider)
+		provider, isProvider := httpClient.(beaconApiClient)
 		if !isProvider {
-			return nil, stacktrace.NewError("unable to cast http client to beacon rpc provider for %s", session.Pod.GetName())
+			return nil, stacktrace.NewError("unable to cast http client to beacon rpc provider for %s", endpoint.Pod.GetName())
 		}
 		return &BeaconClientRpc{
-			session: session,
-			client:  provider,
+			endpoint:    endpoint,
+			client:      provider,
+			address:     address,
+			httpClient:  &nethttp.Client{},
+			callTimeout: callTimeout,
 		}, nil
 	}
 	return nil, stacktrace.NewError("unreachable beacon rpc")
 }
 
+// callJson calls a beacon API endpoint directly and decodes the JSON response into out. Used for endpoints
+// go-eth2-client doesn't support.
+func (c *BeaconClientRpc) callJson(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return stacktrace.Propagate(err, "unable to encode request body for %s", path)
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+	req, err := nethttp.NewRequestWithContext(ctx, method, c.address+path, reqBody)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to build request for %s", path)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return stacktrace.Propagate(err, "request for %s to %s failed", path, c.endpoint.Pod.GetName())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK {
+		return stacktrace.NewError("request for %s to %s returned status %d", path, c.endpoint.Pod.GetName(), resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to decode response for %s from %s", path, c.endpoint.Pod.GetName())
+	}
+	return nil
+}
+
+func (c *BeaconClientRpc) markStale() {
+	c.stale.Store(true)
+}
+
 func (c *BeaconClientRpc) Close() {
-	c.session.Close()
+	c.endpoint.Close()
+}
+
+func (c *BeaconClientRpc) headerByHash(ctx context.Context, root string) (*blockHeader, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	result, err := c.client.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{Block: "0x" + root})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to fetch block %s from %s", root, c.endpoint.Pod.GetName())
+	}
+	return &blockHeader{
+		number: uint64(result.Data.Header.Message.Slot),
+		hash:   hex.EncodeToString(result.Data.Root[:]),
+		parent: hex.EncodeToString(result.Data.Header.Message.ParentRoot[:]),
+	}, nil
+}
+
+func (c *BeaconClientRpc) headerByNumber(ctx context.Context, slot uint64) (*blockHeader, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	result, err := c.client.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{Block: strconv.FormatUint(slot, 10)})
+	var apiErr *api.Error
+	// empty slots have no block
+	if errors.As(err, &apiErr) && apiErr.StatusCode == nethttp.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to fetch slot %d from %s", slot, c.endpoint.Pod.GetName())
+	}
+	return &blockHeader{
+		number: uint64(result.Data.Header.Message.Slot),
+		hash:   hex.EncodeToString(result.Data.Root[:]),
+		parent: hex.EncodeToString(result.Data.Header.Message.ParentRoot[:]),
+	}, nil
 }
 
 func (c *BeaconClientRpc) GetLatestBlockBy(ctx context.Context, blockType string) (*ClientForkChoice, error) {
-	// todo: handle pods that died and we didn't expect it
 	result, err := c.client.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{Block: blockType})
 	if err != nil {
 		var apiErr *api.Error
@@ -161,7 +205,7 @@ func (c *BeaconClientRpc) GetLatestBlockBy(ctx context.Context, blockType string
 			case 404:
 				if blockType == "finalized" {
 					choice := &ClientForkChoice{
-						Pod:         c.session.Pod,
+						Pod:         c.endpoint.Pod,
 						BlockNumber: 0,
 						BlockHash:   "None",
 					}
@@ -172,29 +216,32 @@ func (c *BeaconClientRpc) GetLatestBlockBy(ctx context.Context, blockType string
 		// chock it up to a failure we need to retry
 		// note: at this time this retry logic isn't actually hooked up. I havent seen any failures to hit this RPC
 		// endpoint yet, so setting up a retry mechanism may just be over-engineering.
+		if isTransportError(err) {
+			c.markStale()
+		}
 		choice := &ClientForkChoice{
-			Pod:         c.session.Pod,
+			Pod:         c.endpoint.Pod,
 			BlockNumber: 0,
 			BlockHash:   "N/A",
 		}
 		return choice, nil
-		//return nil, stacktrace.Propagate(err, "Unable to query for blockType %s with client for %s", blockType, c.session.Pod.GetName())
+		//return nil, stacktrace.Propagate(err, "Unable to query for blockType %s with client for %s", blockType, c.endpoint.Pod.GetName())
 	}
 
 	slot := uint64(result.Data.Header.Message.Slot)
-	bodyHash := hex.EncodeToString(result.Data.Header.Message.BodyRoot[:])
+	blockRoot := hex.EncodeToString(result.Data.Root[:])
 
 	if slot == 0 && blockType == "finalized" {
 		return &ClientForkChoice{
-			Pod:         c.session.Pod,
+			Pod:         c.endpoint.Pod,
 			BlockNumber: slot,
 			BlockHash:   "None",
 		}, nil
 	} else {
 		return &ClientForkChoice{
-			Pod:         c.session.Pod,
+			Pod:         c.endpoint.Pod,
 			BlockNumber: slot,
-			BlockHash:   bodyHash,
+			BlockHash:   blockRoot,
 		}, nil
 	}
 }
@@ -1,9 +1,11 @@
 package ethereum
 
 import (
+	"attacknet/cmd/pkg/health/types"
 	"attacknet/cmd/pkg/kubernetes"
 	"context"
 	log "github.com/sirupsen/logrus"
+	"sort"
 	"time"
 )
 
@@ -17,101 +19,136 @@ type ClientForkChoice struct {
 	BlockHash   string
 }
 
-func getExecNetworkConsensus(ctx context.Context, nodeClients []*ExecClientRPC, blockType string) ([]*ClientForkChoice, error) {
-	clientForkVotes := make([]*ClientForkChoice, len(nodeClients))
-	for i, client := range nodeClients {
-		choice, err := client.GetLatestBlockBy(ctx, blockType)
-		if err != nil {
-			return nil, err
-		}
-
-		clientForkVotes[i] = choice
-	}
-	return clientForkVotes, nil
+func (e *EthNetworkChecker) getExecNetworkConsensus(ctx context.Context, nodeClients []*ExecClientRPC, blockType string) ([]*ClientForkChoice, error) {
+	return queryConcurrently(ctx, nodeClients, e.rpcPool.callTimeout, func(ctx context.Context, client *ExecClientRPC) (*ClientForkChoice, error) {
+		return client.GetLatestBlockBy(ctx, blockType)
+	})
 }
 
-func getBeaconNetworkConsensus(ctx context.Context, nodeClients []*BeaconClientRpc, blockType string) ([]*ClientForkChoice, error) {
-	clientForkVotes := make([]*ClientForkChoice, len(nodeClients))
-	for i, client := range nodeClients {
-		choice, err := client.GetLatestBlockBy(ctx, blockType)
-		if err != nil {
-			return nil, err
-		}
+func (e *EthNetworkChecker) getBeaconNetworkConsensus(ctx context.Context, nodeClients []*BeaconClientRpc, blockType string) ([]*ClientForkChoice, error) {
+	return queryConcurrently(ctx, nodeClients, e.rpcPool.callTimeout, func(ctx context.Context, client *BeaconClientRpc) (*ClientForkChoice, error) {
+		return client.GetLatestBlockBy(ctx, blockType)
+	})
+}
 
-		clientForkVotes[i] = choice
-	}
-	return clientForkVotes, nil
+// forkConsensus is the outcome of comparing the blocks reported by each client.
+type forkConsensus struct {
+	// the clients in the majority partition. when there's no majority, the clients in the largest partition
+	consensus []*ClientForkChoice
+	// clients at a different block number than the consensus
+	wrongBlockNum []*ClientForkChoice
+	// clients at the consensus block number but with a different hash
+	wrongBlockHash []*ClientForkChoice
+	noMajority     bool
+	// every group of clients reporting the same block, largest first
+	partitions [][]*ClientForkChoice
 }
 
-func determineForkConsensus(nodes []*ClientForkChoice) (
-	consensusBlockNum []*ClientForkChoice,
-	wrongBlockNum []*ClientForkChoice,
-	consensusBlockHash []*ClientForkChoice,
-	wrongBlockHash []*ClientForkChoice) {
+func (f *forkConsensus) inConsensus() bool {
+	return !f.noMajority && len(f.wrongBlockNum) == 0 && len(f.wrongBlockHash) == 0
+}
 
-	// convert node votes to map
-	blockVotes := make(map[uint64][]*ClientForkChoice)
+// determineForkConsensus groups the clients by the block number and hash they report. A block is only the consensus
+// if a strict majority of the clients report it, so an even split never passes. Partitions are ordered by size, then by
+// block number (highest first), then by hash, so ties are broken the same way every time.
+func determineForkConsensus(nodes []*ClientForkChoice) *forkConsensus {
+	type blockId struct {
+		number uint64
+		hash   string
+	}
+	votes := make(map[blockId][]*ClientForkChoice)
 	for _, vote := range nodes {
-		blockVotes[vote.BlockNumber] = append(blockVotes[vote.BlockNumber], vote)
+		id := blockId{vote.BlockNumber, vote.BlockHash}
+		votes[id] = append(votes[id], vote)
 	}
 
-	//var consensusBlock uint64
-	var consensusBlockVotes int
+	result := &forkConsensus{}
+	for _, partition := range votes {
+		sort.Slice(partition, func(i, j int) bool { return partition[i].Pod.GetName() < partition[j].Pod.GetName() })
+		result.partitions = append(result.partitions, partition)
+	}
+	sort.Slice(result.partitions, func(i, j int) bool {
+		a, b := result.partitions[i], result.partitions[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		if a[0].BlockNumber != b[0].BlockNumber {
+			return a[0].BlockNumber > b[0].BlockNumber
+		}
+		return a[0].BlockHash < b[0].BlockHash
+	})
+	if len(result.partitions) == 0 {
+		result.noMajority = true
+		return result
+	}
 
-	// determine consensus block height
-	for _, v := range blockVotes {
-		if len(v) > consensusBlockVotes {
-			if consensusBlockVotes != 0 {
-				wrongBlockNum = append(wrongBlockNum, consensusBlockNum...)
-			}
-			//consensusBlock = k
-			consensusBlockVotes = len(v)
-			consensusBlockNum = v
+	result.consensus = result.partitions[0]
+	result.noMajority = len(result.consensus)*2 <= len(nodes)
+	consensusBlock := result.consensus[0]
+	for _, partition := range result.partitions[1:] {
+		if partition[0].BlockNumber == consensusBlock.BlockNumber {
+			result.wrongBlockHash = append(result.wrongBlockHash, partition...)
 		} else {
-			wrongBlockNum = append(wrongBlockNum, v...)
+			result.wrongBlockNum = append(result.wrongBlockNum, partition...)
 		}
 	}
+	return result
+}
 
-	// for the consensus block height, determine the consensus hash
-	var hashVotes = make(map[string][]*ClientForkChoice)
-	for _, vote := range consensusBlockNum {
-		hashVotes[vote.BlockHash] = append(hashVotes[vote.BlockHash], vote)
+// toTestResult converts the outcome to a test result. forkStatus may be nil.
+func (f *forkConsensus) toTestResult(forkStatus map[string]*types.NodeForkStatus) *types.BlockConsensusTestResult {
+	result := &types.BlockConsensusTestResult{
+		FailingClientsReportedBlock: make(map[string]uint64),
+		FailingClientsReportedHash:  make(map[string]string),
+		ForkStatus:                  forkStatus,
+		NoMajority:                  f.noMajority,
+	}
+	if len(f.consensus) > 0 {
+		result.ConsensusBlock = f.consensus[0].BlockNumber
+		result.ConsensusHash = f.consensus[0].BlockHash
+	}
+	for _, node := range f.wrongBlockNum {
+		result.FailingClientsReportedBlock[node.Pod.GetName()] = node.BlockNumber
 	}
-	//var consensusHash string
-	var consensusHashVotes int
-	for _, v := range hashVotes {
-		if len(v) > consensusHashVotes {
-			if consensusBlockVotes != 0 {
-				wrongBlockHash = append(wrongBlockHash, consensusBlockHash...)
+	for _, node := range f.wrongBlockHash {
+		result.FailingClientsReportedHash[node.Pod.GetName()] = node.BlockHash
+	}
+	if f.noMajority {
+		for _, partition := range f.partitions {
+			p := &types.ConsensusPartition{BlockNumber: partition[0].BlockNumber, BlockHash: partition[0].BlockHash}
+			for _, node := range partition {
+				p.Clients = append(p.Clients, node.Pod.GetName())
 			}
-			//consensusHash = k
-			consensusHashVotes = len(v)
-			consensusBlockHash = v
-		} else {
-			wrongBlockHash = append(wrongBlockHash, v...)
+			result.Partitions = append(result.Partitions, p)
 		}
 	}
-	return
+	return result
 }
 
-func reportConsensusDataToLogger(consensusType string,
-	consensusBlockNum []*ClientForkChoice,
-	wrongBlockNum []*ClientForkChoice,
-	consensusBlockHash []*ClientForkChoice,
-	wrongBlockHash []*ClientForkChoice) {
+func reportConsensusDataToLogger(consensusType string, consensus *forkConsensus) {
+	if consensus.noMajority {
+		log.Warnf("No strict majority of nodes agree on the '%s' block. Time: %d", consensusType, time.Now().Unix())
+		for _, partition := range consensus.partitions {
+			log.Warnf("---> Partition of %d nodes at %s BlockHeight: %d BlockHash: %s", len(partition), consensusType, partition[0].BlockNumber, partition[0].BlockHash)
+			for _, n := range partition {
+				log.Warnf("------> Node: %s", n.Pod.GetName())
+			}
+		}
+		return
+	}
 
-	log.Infof("Consensus %s block height: %d", consensusType, consensusBlockNum[0].BlockNumber)
-	if len(wrongBlockNum) > 0 {
+	log.Infof("Consensus %s block height: %d", consensusType, consensus.consensus[0].BlockNumber)
+	if len(consensus.wrongBlockNum) > 0 {
 		log.Warnf("Some nodes are out of consensus for block type '%s'. Time: %d", consensusType, time.Now().Unix())
-		for _, n := range wrongBlockNum {
+		for _, n := range consensus.wrongBlockNum {
 			log.Warnf("---> Node: %s %s BlockHeight: %d BlockHash: %s", n.Pod.GetName(), consensusType, n.BlockNumber, n.BlockHash)
 		}
 	}
 
-	log.Infof("Consensus %s block hash: %s", consensusType, consensusBlockHash[0].BlockHash)
-	if len(wrongBlockHash) > 0 {
+	log.Infof("Consensus %s block hash: %s", consensusType, consensus.consensus[0].BlockHash)
+	if len(consensus.wrongBlockHash) > 0 {
 		log.Warnf("Some nodes are at the correct height, but with the wrong '%s' block hash", consensusType)
-		for _, n := range wrongBlockHash {
+		for _, n := range consensus.wrongBlockHash {
 			log.Warnf("---> Node: %s %s BlockHeight: %d BlockHash: %s", n.Pod.GetName(), consensusType, n.BlockNumber, n.BlockHash)
 		}
 	}
@@ -0,0 +1,76 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	"testing"
+)
+
+func forkChoice(pod string, number uint64, hash string) *ClientForkChoice {
+	return &ClientForkChoice{Pod: &kubernetes.Pod{Name: pod}, BlockNumber: number, BlockHash: hash}
+}
+
+func TestDetermineForkConsensus(t *testing.T) {
+	consensus := determineForkConsensus([]*ClientForkChoice{
+		forkChoice("el-1", 10, "a"),
+		forkChoice("el-2", 10, "a"),
+		forkChoice("el-3", 10, "b"),
+		forkChoice("el-4", 9, "c"),
+		forkChoice("el-5", 10, "a"),
+	})
+	if consensus.noMajority || consensus.inConsensus() {
+		t.Fatalf("expected a majority with failing nodes, got %+v", consensus)
+	}
+	if consensus.consensus[0].BlockHash != "a" || len(consensus.consensus) != 3 {
+		t.Fatalf("expected block a to be the consensus, got %s", consensus.consensus[0].BlockHash)
+	}
+	if len(consensus.wrongBlockHash) != 1 || consensus.wrongBlockHash[0].Pod.GetName() != "el-3" {
+		t.Fatalf("expected el-3 to report the wrong hash, got %v", consensus.wrongBlockHash)
+	}
+	if len(consensus.wrongBlockNum) != 1 || consensus.wrongBlockNum[0].Pod.GetName() != "el-4" {
+		t.Fatalf("expected el-4 to report the wrong block, got %v", consensus.wrongBlockNum)
+	}
+}
+
+func TestDetermineForkConsensusEvenSplit(t *testing.T) {
+	// run several times, since map iteration order used to decide the outcome
+	for i := 0; i < 20; i++ {
+		consensus := determineForkConsensus([]*ClientForkChoice{
+			forkChoice("cl-1", 10, "a"),
+			forkChoice("cl-2", 11, "b"),
+			forkChoice("cl-3", 10, "a"),
+			forkChoice("cl-4", 11, "b"),
+		})
+		if !consensus.noMajority || consensus.inConsensus() {
+			t.Fatal("expected an even split to have no majority")
+		}
+		if consensus.consensus[0].BlockNumber != 11 {
+			t.Fatalf("expected ties to go to the highest block, got %d", consensus.consensus[0].BlockNumber)
+		}
+		result := consensus.toTestResult(nil)
+		if len(result.Partitions) != 2 || result.Partitions[0].Clients[0] != "cl-2" || result.Partitions[1].Clients[1] != "cl-3" {
+			t.Fatalf("unexpected partitions: %+v %+v", result.Partitions[0], result.Partitions[1])
+		}
+	}
+
+	// hashes split evenly at the same height
+	consensus := determineForkConsensus([]*ClientForkChoice{
+		forkChoice("cl-1", 10, "a"),
+		forkChoice("cl-2", 10, "b"),
+	})
+	if !consensus.noMajority {
+		t.Fatal("expected an even hash split to have no majority")
+	}
+}
+
+func TestDetermineForkConsensusAgreement(t *testing.T) {
+	consensus := determineForkConsensus([]*ClientForkChoice{
+		forkChoice("el-1", 0, "None"),
+		forkChoice("el-2", 0, "None"),
+	})
+	if !consensus.inConsensus() {
+		t.Fatalf("expected nodes to be in consensus, got %+v", consensus)
+	}
+	if len(determineForkConsensus(nil).partitions) != 0 || determineForkConsensus(nil).inConsensus() {
+		t.Fatal("expected no nodes to have no majority")
+	}
+}
@@ -4,106 +4,122 @@ import (
 	"attacknet/cmd/pkg/health/types"
 	"attacknet/cmd/pkg/kubernetes"
 	"context"
-	"fmt"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	geth "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/kurtosis-tech/stacktrace"
 	log "github.com/sirupsen/logrus"
+	"math/big"
+	"sync/atomic"
 	"time"
 )
 
 type ExecClientRPC struct {
-	session *kubernetes.PortForwardsSession
-	client  *ethclient.Client
+	endpoint    *kubernetes.PodEndpoint
+	client      *ethclient.Client
+	callTimeout time.Duration
+	stale       atomic.Bool // set when a call failed in a way that needs a new connection
 }
 
 func (e *EthNetworkChecker) getExecBlockConsensus(ctx context.Context, clients []*ExecClientRPC, blockType string, maxAttempts int) (*types.BlockConsensusTestResult, error) {
-	forkChoice, err := getExecNetworkConsensus(ctx, clients, blockType)
+	forkChoice, err := e.getExecNetworkConsensus(ctx, clients, blockType)
 	if err != nil {
 		return nil, err
 	}
 	// determine whether the nodes are in consensus
-	consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash := determineForkConsensus(forkChoice)
-	if len(wrongBlockNum) > 0 {
-		if maxAttempts > 0 {
-			log.Debugf("Nodes not at consensus for %s block. Waiting and re-trying in case we're on block propagation boundary. Attempts left: %d", blockType, maxAttempts-1)
-			time.Sleep(1 * time.Second)
-			return e.getExecBlockConsensus(ctx, clients, blockType, maxAttempts-1)
-		} else {
-			reportConsensusDataToLogger(blockType, consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash)
-		}
+	consensus := determineForkConsensus(forkChoice)
+	if !consensus.inConsensus() && maxAttempts > 0 {
+		log.Debugf("Nodes not at consensus for %s block. Waiting and re-trying in case we're on block propagation boundary. Attempts left: %d", blockType, maxAttempts-1)
+		time.Sleep(1 * time.Second)
+		return e.getExecBlockConsensus(ctx, clients, blockType, maxAttempts-1)
 	}
 
-	blockNumWrong := make(map[string]uint64)
-	for _, node := range wrongBlockNum {
-		blockNumWrong[node.Pod.GetName()] = node.BlockNumber
+	var forkStatus map[string]*types.NodeForkStatus
+	if blockType == "latest" {
+		readers := make(map[string]chainReader)
+		for _, client := range clients {
+			readers[client.endpoint.Pod.GetName()] = client
+		}
+		e.detectReorgs(ctx, readers, forkChoice)
+		if !consensus.inConsensus() && len(consensus.consensus) > 0 {
+			var failing []*ClientForkChoice
+			failing = append(failing, consensus.wrongBlockNum...)
+			failing = append(failing, consensus.wrongBlockHash...)
+			forkStatus = classifyForks(ctx, readers, consensus.consensus[0], failing)
+		}
 	}
 
-	blockHashWrong := make(map[string]string)
-
-	for _, node := range wrongBlockHash {
-		blockHashWrong[node.Pod.GetName()] = node.BlockHash
-	}
-	reportConsensusDataToLogger(blockType, consensusBlockNum, wrongBlockNum, consensusBlockHash, wrongBlockHash)
-	return &types.BlockConsensusTestResult{
-		ConsensusBlock:              (consensusBlockNum)[0].BlockNumber,
-		ConsensusHash:               consensusBlockHash[0].BlockHash,
-		FailingClientsReportedBlock: blockNumWrong,
-		FailingClientsReportedHash:  blockHashWrong,
-	}, nil
+	reportConsensusDataToLogger(blockType, consensus)
+	return consensus.toTestResult(forkStatus), nil
 }
 
-func (e *EthNetworkChecker) dialToExecutionClients(ctx context.Context) ([]*ExecClientRPC, error) {
-	labelKey := "kurtosistech.com.custom/ethereum-package.client-type"
-	labelValue := "execution"
-	podsToHealthCheck, err := getPodsToHealthCheck(
+func (e *EthNetworkChecker) dialToExecutionClients(ctx context.Context) ([]*ExecClientRPC, []string, error) {
+	podsToHealthCheck, podsNotRestarted, err := e.getPodsToHealthCheck(
 		ctx,
-		e.kubeClient,
-		e.podsUnderTest,
-		e.podsUnderTestLookup,
-		labelKey,
-		labelValue)
+		e.clientSchema.ClientTypeLabel,
+		e.clientSchema.ExecutionLabelValue)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	log.Debugf("Starting port forward sessions to %d pods", len(podsToHealthCheck))
-	portForwardSessions, err := e.kubeClient.StartMultiPortForwardToLabeledPods(
-		podsToHealthCheck,
-		labelKey,
-		labelValue,
-		8545)
+	rpcClients, err := e.rpcPool.ExecClients(ctx, podsToHealthCheck)
 	if err != nil {
-		return nil, err
-	}
-
-	// dial out to clients
-	rpcClients := make([]*ExecClientRPC, len(portForwardSessions))
-	for i, s := range portForwardSessions {
-		client, err := dialExecRpcClient(s)
-		if err != nil {
-			return nil, err
-		}
-		rpcClients[i] = client
+		return nil, nil, err
 	}
-	return rpcClients, nil
+	return rpcClients, podsNotRestarted, nil
 }
 
-func dialExecRpcClient(session *kubernetes.PortForwardsSession) (*ExecClientRPC, error) {
-	c, err := ethclient.Dial(fmt.Sprintf("http://localhost:%d", session.LocalPort))
+func dialExecRpcClient(endpoint *kubernetes.PodEndpoint, callTimeout time.Duration) (*ExecClientRPC, error) {
+	c, err := ethclient.Dial("http://" + endpoint.Address)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "err while dialing RPC for %s", session.Pod.GetName())
+		return nil, stacktrace.Propagate(err, "err while dialing RPC for %s", endpoint.Pod.GetName())
 	}
-	return &ExecClientRPC{session: session, client: c}, nil
+	return &ExecClientRPC{endpoint: endpoint, client: c, callTimeout: callTimeout}, nil
+}
+
+func (c *ExecClientRPC) markStale() {
+	c.stale.Store(true)
 }
 
 func (c *ExecClientRPC) Close() {
 	c.client.Close()
-	c.session.Close()
+	c.endpoint.Close()
+}
+
+func (c *ExecClientRPC) headerByHash(ctx context.Context, hash string) (*blockHeader, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	header, err := c.client.HeaderByHash(ctx, common.HexToHash(hash))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to fetch block %s from %s", hash, c.endpoint.Pod.GetName())
+	}
+	return &blockHeader{
+		number: header.Number.Uint64(),
+		hash:   header.Hash().String(),
+		parent: header.ParentHash.String(),
+	}, nil
+}
+
+func (c *ExecClientRPC) headerByNumber(ctx context.Context, number uint64) (*blockHeader, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to fetch block %d from %s", number, c.endpoint.Pod.GetName())
+	}
+	return &blockHeader{
+		number: header.Number.Uint64(),
+		hash:   header.Hash().String(),
+		parent: header.ParentHash.String(),
+	}, nil
 }
 
 func (c *ExecClientRPC) GetLatestBlockBy(ctx context.Context, blockType string) (*ClientForkChoice, error) {
-	// todo: handle pods that died and we didn't expect it
 	var head *geth.Header
 	var choice *ClientForkChoice
 	err := c.client.Client().CallContext(ctx, &head, "eth_getBlockByNumber", blockType, false)
@@ -126,12 +142,22 @@ func (c *ExecClientRPC) GetLatestBlockBy(ctx context.Context, blockType string)
 
 		if noFinalBlockFound {
 			choice = &ClientForkChoice{
-				Pod:         c.session.Pod,
+				Pod:         c.endpoint.Pod,
 				BlockNumber: 0,
 				BlockHash:   "None",
 			}
 		} else {
-			return nil, stacktrace.Propagate(err, "error while calling RPC for client %s", c.session.Pod.GetName())
+			// an unreachable client votes N/A like the beacon client does, so one client can't abort the health checks.
+			// the pool redials it next round
+			if isTransportError(err) {
+				c.markStale()
+			}
+			log.Warnf("Unable to query the %s block of %s: %s", blockType, c.endpoint.Pod.GetName(), err)
+			choice = &ClientForkChoice{
+				Pod:         c.endpoint.Pod,
+				BlockNumber: 0,
+				BlockHash:   "N/A",
+			}
 		}
 	} else {
 		blockNum := head.Number.Uint64()
@@ -143,7 +169,7 @@ func (c *ExecClientRPC) GetLatestBlockBy(ctx context.Context, blockType string)
 		choice = &ClientForkChoice{
 			BlockNumber: blockNum,
 			BlockHash:   hash,
-			Pod:         c.session.Pod,
+			Pod:         c.endpoint.Pod,
 		}
 	}
 	return choice, nil
@@ -0,0 +1,66 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	"context"
+	"github.com/ethereum/go-ethereum/ethclient"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const zeroHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+var testHeaderJson = `{"parentHash":"` + zeroHash + `","sha3Uncles":"` + zeroHash + `",` +
+	`"miner":"0x0000000000000000000000000000000000000000","stateRoot":"` + zeroHash + `",` +
+	`"transactionsRoot":"` + zeroHash + `","receiptsRoot":"` + zeroHash + `",` +
+	`"logsBloom":"0x` + strings.Repeat("00", 256) + `","difficulty":"0x0","number":"0x10",` +
+	`"gasLimit":"0x1c9c380","gasUsed":"0x0","timestamp":"0x1","extraData":"0x"}`
+
+func TestExecConsensusSurvivesTimeout(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+	healthy := jsonRpcServer(t, map[string]string{"eth_getBlockByNumber": testHeaderJson})
+	defer healthy.Close()
+
+	var clients []*ExecClientRPC
+	for i, url := range []string{healthy.URL, healthy.URL, hanging.URL} {
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		clients = append(clients, &ExecClientRPC{
+			endpoint:    &kubernetes.PodEndpoint{Pod: &kubernetes.Pod{Name: []string{"el-1", "el-2", "el-3"}[i]}},
+			client:      client,
+			callTimeout: 100 * time.Millisecond,
+		})
+	}
+
+	checker := &EthNetworkChecker{rpcPool: &RpcPool{callTimeout: 100 * time.Millisecond}}
+	result, err := checker.getExecBlockConsensus(context.Background(), clients, "finalized", 0)
+	if err != nil {
+		t.Fatalf("a timed out client shouldn't fail the round: %s", err)
+	}
+	if result.ConsensusBlock != 16 {
+		t.Errorf("expected consensus on block 16, got %d", result.ConsensusBlock)
+	}
+	if _, ok := result.FailingClientsReportedBlock["el-3"]; !ok {
+		t.Errorf("expected el-3 to be failing, got %+v", result.FailingClientsReportedBlock)
+	}
+	if len(result.FailingClientsReportedBlock) != 1 {
+		t.Errorf("expected only el-3 to be failing, got %+v", result.FailingClientsReportedBlock)
+	}
+	if !clients[2].stale.Load() {
+		t.Error("expected the timed out client to be marked stale")
+	}
+	if clients[0].stale.Load() || clients[1].stale.Load() {
+		t.Error("expected the healthy clients to be kept")
+	}
+}
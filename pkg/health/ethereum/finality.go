@@ -0,0 +1,136 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+// getFinalityResult compares the justified and finalized checkpoints of every beacon node and checks how far the
+// finalized epoch trails the consensus head slot.
+func (e *EthNetworkChecker) getFinalityResult(ctx context.Context, clients []*BeaconClientRpc, headSlot uint64, maxAttempts int) (*types.FinalityResult, error) {
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	slotsPerEpoch, err := e.getSlotsPerEpoch(ctx, clients[0])
+	if err != nil {
+		return nil, err
+	}
+
+	results, _ := queryConcurrently(ctx, clients, e.rpcPool.callTimeout, func(ctx context.Context, client *BeaconClientRpc) (*types.FinalityCheckpoints, error) {
+		return client.GetFinalityCheckpoints(ctx), nil
+	})
+	checkpoints := make(map[string]*types.FinalityCheckpoints)
+	for i, client := range clients {
+		checkpoints[client.endpoint.Pod.GetName()] = results[i]
+	}
+	consensus, failing := determineCheckpointConsensus(checkpoints)
+	if len(failing) > 0 && maxAttempts > 0 {
+		// justification and finalization are updated at epoch boundaries, so nodes may briefly disagree
+		log.Debugf("Nodes not at consensus for finality checkpoints. Waiting and re-trying. Attempts left: %d", maxAttempts-1)
+		time.Sleep(1 * time.Second)
+		return e.getFinalityResult(ctx, clients, headSlot, maxAttempts-1)
+	}
+
+	result := &types.FinalityResult{
+		ConsensusCheckpoints:              consensus,
+		FailingClientsReportedCheckpoints: failing,
+		HeadEpoch:                         headSlot / slotsPerEpoch,
+		MaxFinalityLagEpochs:              e.maxFinalityLagEpochs,
+	}
+	if result.HeadEpoch > consensus.FinalizedEpoch {
+		result.FinalityLagEpochs = result.HeadEpoch - consensus.FinalizedEpoch
+	}
+	if e.maxFinalityLagEpochs != nil && result.FinalityLagEpochs > uint64(*e.maxFinalityLagEpochs) {
+		result.FinalityLagExceeded = true
+		log.Warnf("Finalized epoch %d trails head epoch %d by %d epochs. At most %d are allowed", consensus.FinalizedEpoch, result.HeadEpoch, result.FinalityLagEpochs, *e.maxFinalityLagEpochs)
+	} else {
+		log.Infof("Finalized epoch %d trails head epoch %d by %d epochs", consensus.FinalizedEpoch, result.HeadEpoch, result.FinalityLagEpochs)
+	}
+	for pod, c := range failing {
+		log.Warnf("---> Node: %s justified: %d/%s finalized: %d/%s %s", pod, c.JustifiedEpoch, c.JustifiedRoot, c.FinalizedEpoch, c.FinalizedRoot, c.Error)
+	}
+	return result, nil
+}
+
+func (e *EthNetworkChecker) getSlotsPerEpoch(ctx context.Context, client *BeaconClientRpc) (uint64, error) {
+	if e.slotsPerEpoch != 0 {
+		return e.slotsPerEpoch, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, client.callTimeout)
+	defer cancel()
+	spec, err := client.client.Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "unable to fetch the beacon spec from %s", client.endpoint.Pod.GetName())
+	}
+	slotsPerEpoch, ok := spec.Data["SLOTS_PER_EPOCH"].(uint64)
+	if !ok || slotsPerEpoch == 0 {
+		return 0, stacktrace.NewError("the beacon spec from %s has no valid SLOTS_PER_EPOCH", client.endpoint.Pod.GetName())
+	}
+	e.slotsPerEpoch = slotsPerEpoch
+	return slotsPerEpoch, nil
+}
+
+// determineCheckpointConsensus picks the checkpoints reported by the most nodes and returns the nodes that reported
+// something else. Ties go to the checkpoints with the highest finalized epoch so the result is stable.
+func determineCheckpointConsensus(checkpoints map[string]*types.FinalityCheckpoints) (*types.FinalityCheckpoints, map[string]*types.FinalityCheckpoints) {
+	votes := make(map[string][]string)
+	byKey := make(map[string]*types.FinalityCheckpoints)
+	for pod, c := range checkpoints {
+		if c.Error != "" {
+			continue
+		}
+		key := checkpointKey(c)
+		votes[key] = append(votes[key], pod)
+		byKey[key] = c
+	}
+
+	var keys []string
+	for key := range votes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(votes[keys[i]]) != len(votes[keys[j]]) {
+			return len(votes[keys[i]]) > len(votes[keys[j]])
+		}
+		if byKey[keys[i]].FinalizedEpoch != byKey[keys[j]].FinalizedEpoch {
+			return byKey[keys[i]].FinalizedEpoch > byKey[keys[j]].FinalizedEpoch
+		}
+		return keys[i] < keys[j]
+	})
+
+	consensus := &types.FinalityCheckpoints{}
+	if len(keys) > 0 {
+		consensus = byKey[keys[0]]
+	}
+	failing := make(map[string]*types.FinalityCheckpoints)
+	for pod, c := range checkpoints {
+		if c.Error != "" || checkpointKey(c) != checkpointKey(consensus) {
+			failing[pod] = c
+		}
+	}
+	return consensus, failing
+}
+
+func checkpointKey(c *types.FinalityCheckpoints) string {
+	return fmt.Sprintf("%d/%s/%d/%s", c.JustifiedEpoch, c.JustifiedRoot, c.FinalizedEpoch, c.FinalizedRoot)
+}
+
+func (c *BeaconClientRpc) GetFinalityCheckpoints(ctx context.Context) *types.FinalityCheckpoints {
+	finality, err := c.client.Finality(ctx, &api.FinalityOpts{State: "head"})
+	if err != nil {
+		return &types.FinalityCheckpoints{Error: fmt.Sprintf("finality_checkpoints failed: %s", err)}
+	}
+	return &types.FinalityCheckpoints{
+		JustifiedEpoch: uint64(finality.Data.Justified.Epoch),
+		JustifiedRoot:  hex.EncodeToString(finality.Data.Justified.Root[:]),
+		FinalizedEpoch: uint64(finality.Data.Finalized.Epoch),
+		FinalizedRoot:  hex.EncodeToString(finality.Data.Finalized.Root[:]),
+	}
+}
@@ -0,0 +1,78 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"testing"
+)
+
+func checkpoints(justified, finalized uint64, root string) *types.FinalityCheckpoints {
+	return &types.FinalityCheckpoints{JustifiedEpoch: justified, JustifiedRoot: root, FinalizedEpoch: finalized, FinalizedRoot: root}
+}
+
+func TestDetermineCheckpointConsensus(t *testing.T) {
+	consensus, failing := determineCheckpointConsensus(map[string]*types.FinalityCheckpoints{
+		"cl-1": checkpoints(5, 4, "a"),
+		"cl-2": checkpoints(5, 4, "a"),
+		"cl-3": checkpoints(5, 4, "a"),
+		"cl-4": checkpoints(3, 2, "b"),
+		"cl-5": {Error: "finality_checkpoints failed: timeout"},
+	})
+	if consensus.FinalizedEpoch != 4 || consensus.FinalizedRoot != "a" {
+		t.Fatalf("expected the majority checkpoints to be the consensus, got %+v", consensus)
+	}
+	if len(failing) != 2 || failing["cl-4"] == nil || failing["cl-5"] == nil {
+		t.Fatalf("expected cl-4 and the unreachable cl-5 to fail, got %v", failing)
+	}
+
+	// same epochs, different roots
+	_, failing = determineCheckpointConsensus(map[string]*types.FinalityCheckpoints{
+		"cl-1": checkpoints(5, 4, "a"),
+		"cl-2": checkpoints(5, 4, "a"),
+		"cl-3": checkpoints(5, 4, "b"),
+	})
+	if len(failing) != 1 || failing["cl-3"] == nil {
+		t.Fatalf("expected cl-3 to fail on its root, got %v", failing)
+	}
+}
+
+func TestDetermineCheckpointConsensusEvenSplit(t *testing.T) {
+	// run several times, since map iteration order could decide the outcome
+	for i := 0; i < 20; i++ {
+		consensus, failing := determineCheckpointConsensus(map[string]*types.FinalityCheckpoints{
+			"cl-1": checkpoints(3, 2, "a"),
+			"cl-2": checkpoints(5, 4, "b"),
+			"cl-3": checkpoints(3, 2, "a"),
+			"cl-4": checkpoints(5, 4, "b"),
+		})
+		if consensus.FinalizedEpoch != 4 {
+			t.Fatalf("expected ties to go to the highest finalized epoch, got %d", consensus.FinalizedEpoch)
+		}
+		if len(failing) != 2 || failing["cl-1"] == nil || failing["cl-3"] == nil {
+			t.Fatalf("expected cl-1 and cl-3 to fail, got %v", failing)
+		}
+	}
+
+	// ties at the same epoch are broken by root
+	for i := 0; i < 20; i++ {
+		consensus, _ := determineCheckpointConsensus(map[string]*types.FinalityCheckpoints{
+			"cl-1": checkpoints(5, 4, "b"),
+			"cl-2": checkpoints(5, 4, "a"),
+		})
+		if consensus.FinalizedRoot != "a" {
+			t.Fatalf("expected a stable tie break, got %s", consensus.FinalizedRoot)
+		}
+	}
+}
+
+func TestDetermineCheckpointConsensusAllUnreachable(t *testing.T) {
+	consensus, failing := determineCheckpointConsensus(map[string]*types.FinalityCheckpoints{
+		"cl-1": {Error: "timeout"},
+		"cl-2": {Error: "timeout"},
+	})
+	if consensus.FinalizedEpoch != 0 || consensus.FinalizedRoot != "" {
+		t.Fatalf("expected empty checkpoints, got %+v", consensus)
+	}
+	if len(failing) != 2 {
+		t.Fatalf("expected every node to fail, got %v", failing)
+	}
+}
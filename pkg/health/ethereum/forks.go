@@ -0,0 +1,163 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"context"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// how many blocks to walk back while looking for a common ancestor before giving up
+const maxForkWalkDepth = 256
+
+type blockHeader struct {
+	number uint64
+	hash   string
+	parent string
+}
+
+// chainReader fetches blocks from a single client.
+type chainReader interface {
+	headerByHash(ctx context.Context, hash string) (*blockHeader, error)
+	// headerByNumber returns the block at number on the client's canonical chain, or nil if it has none, e.g. for an
+	// empty slot.
+	headerByNumber(ctx context.Context, number uint64) (*blockHeader, error)
+}
+
+// onChain checks whether header is on the canonical chain of reader with a single lookup.
+func onChain(ctx context.Context, reader chainReader, header *blockHeader) (bool, error) {
+	atNumber, err := reader.headerByNumber(ctx, header.number)
+	if err != nil {
+		return false, err
+	}
+	return atNumber != nil && atNumber.hash == header.hash, nil
+}
+
+// findCommonAncestor walks both chains back until they meet. a is read from readerA, b from readerB.
+func findCommonAncestor(ctx context.Context, readerA chainReader, a *blockHeader, readerB chainReader, b *blockHeader) (*blockHeader, error) {
+	var err error
+	for steps := 0; a.hash != b.hash; steps++ {
+		if steps > maxForkWalkDepth {
+			return nil, stacktrace.NewError("no common ancestor within %d blocks", maxForkWalkDepth)
+		}
+		if a.number >= b.number {
+			a, err = readerA.headerByHash(ctx, a.parent)
+		} else {
+			b, err = readerB.headerByHash(ctx, b.parent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// classifyForks determines whether each failing client is lagging behind the consensus head, ahead of it, on a
+// different branch, or unreachable.
+func classifyForks(ctx context.Context, readers map[string]chainReader, consensusHead *ClientForkChoice, failing []*ClientForkChoice) map[string]*types.NodeForkStatus {
+	statuses := make(map[string]*types.NodeForkStatus)
+	canonicalReader := readers[consensusHead.Pod.GetName()]
+	canonical, err := canonicalReader.headerByHash(ctx, consensusHead.BlockHash)
+	if err != nil {
+		log.Warnf("Unable to fetch the consensus head from %s, skipping fork classification: %s", consensusHead.Pod.GetName(), err)
+		return statuses
+	}
+
+	for _, node := range failing {
+		name := node.Pod.GetName()
+		if _, done := statuses[name]; done {
+			continue
+		}
+		status := classifyFork(ctx, canonicalReader, canonical, readers[name], node)
+		statuses[name] = status
+		log.Warnf("---> Node: %s is %s. depth: %d common ancestor: %d %s", name, status.Status, status.Depth, status.CommonAncestor, status.Error)
+	}
+	return statuses
+}
+
+func classifyFork(ctx context.Context, canonicalReader chainReader, canonical *blockHeader, nodeReader chainReader, node *ClientForkChoice) *types.NodeForkStatus {
+	// the beacon client reports N/A when its head couldn't be queried
+	if node.BlockHash == "N/A" {
+		return &types.NodeForkStatus{Status: types.ForkStatusUnreachable, Error: "unable to query the head"}
+	}
+	// every chain shares genesis
+	if node.BlockNumber == 0 {
+		return &types.NodeForkStatus{Status: types.ForkStatusLagging, Depth: canonical.number}
+	}
+	nodeHead, err := nodeReader.headerByHash(ctx, node.BlockHash)
+	if err != nil {
+		return &types.NodeForkStatus{Status: types.ForkStatusUnreachable, Error: err.Error()}
+	}
+
+	// a node on the canonical chain is found with one lookup, however far behind or ahead it is
+	if nodeHead.number <= canonical.number {
+		lagging, err := onChain(ctx, canonicalReader, nodeHead)
+		if err != nil {
+			return &types.NodeForkStatus{Status: types.ForkStatusUnknown, Error: err.Error()}
+		}
+		if lagging {
+			return &types.NodeForkStatus{Status: types.ForkStatusLagging, Depth: canonical.number - nodeHead.number, CommonAncestor: nodeHead.number}
+		}
+	} else {
+		ahead, err := onChain(ctx, nodeReader, canonical)
+		if err != nil {
+			return &types.NodeForkStatus{Status: types.ForkStatusUnknown, Error: err.Error()}
+		}
+		if ahead {
+			return &types.NodeForkStatus{Status: types.ForkStatusAhead, Depth: nodeHead.number - canonical.number, CommonAncestor: canonical.number}
+		}
+	}
+
+	// the node is on another branch. only walk back to find where it split off
+	ancestor, err := findCommonAncestor(ctx, canonicalReader, canonical, nodeReader, nodeHead)
+	if err != nil {
+		return &types.NodeForkStatus{Status: types.ForkStatusUnknown, Error: err.Error()}
+	}
+	return &types.NodeForkStatus{Status: types.ForkStatusForked, Depth: nodeHead.number - ancestor.number, CommonAncestor: ancestor.number}
+}
+
+// detectReorgs compares the head of each client against the head it reported in the previous round and records the
+// clients whose new head doesn't descend from the old one.
+func (e *EthNetworkChecker) detectReorgs(ctx context.Context, readers map[string]chainReader, heads []*ClientForkChoice) {
+	for _, head := range heads {
+		name := head.Pod.GetName()
+		reader := readers[name]
+		if head.BlockNumber == 0 || reader == nil {
+			continue
+		}
+		newHead, err := reader.headerByHash(ctx, head.BlockHash)
+		if err != nil {
+			continue
+		}
+		oldHead, seen := e.lastHeads[name]
+		e.lastHeads[name] = newHead
+		if !seen || oldHead.hash == newHead.hash {
+			continue
+		}
+		// the common case: the old head is still on the client's chain
+		if oldHead.number <= newHead.number {
+			extended, err := onChain(ctx, reader, oldHead)
+			if err == nil && extended {
+				continue
+			}
+		}
+
+		ancestor, err := findCommonAncestor(ctx, reader, oldHead, reader, newHead)
+		if err != nil {
+			log.Debugf("Unable to check %s for a reorg: %s", name, err)
+			continue
+		}
+		if ancestor.hash != oldHead.hash {
+			reorg := &types.Reorg{
+				Pod:     name,
+				OldHead: oldHead.hash,
+				NewHead: newHead.hash,
+				Depth:   oldHead.number - ancestor.number,
+				Time:    time.Now().Unix(),
+			}
+			log.Warnf("Reorg on %s. Dropped %d blocks, old head: %s new head: %s", name, reorg.Depth, reorg.OldHead, reorg.NewHead)
+			e.reorgs = append(e.reorgs, reorg)
+		}
+	}
+}
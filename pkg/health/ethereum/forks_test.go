@@ -0,0 +1,262 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// fakeChain holds every block known to the test, across branches.
+type fakeChain map[string]*blockHeader
+
+// extend adds length blocks named <prefix><number> on top of parent and returns the new head.
+func (c fakeChain) extend(parent *blockHeader, prefix string, length int) *blockHeader {
+	head := parent
+	for i := 0; i < length; i++ {
+		number := head.number + 1
+		header := &blockHeader{number: number, hash: fmt.Sprintf("%s%d", prefix, number), parent: head.hash}
+		c[header.hash] = header
+		head = header
+	}
+	return head
+}
+
+// node returns a client following the branch that ends at head.
+func (c fakeChain) node(head string) *fakeNode {
+	return &fakeNode{chain: c, head: head}
+}
+
+// fakeNode serves headers from memory and counts the lookups. Unknown hashes return an error, like a client that
+// doesn't have the block.
+type fakeNode struct {
+	chain fakeChain
+	head  string
+	calls int
+}
+
+func (n *fakeNode) headerByHash(_ context.Context, hash string) (*blockHeader, error) {
+	n.calls++
+	header, ok := n.chain[hash]
+	if !ok {
+		return nil, fmt.Errorf("unknown block %s", hash)
+	}
+	return header, nil
+}
+
+func (n *fakeNode) headerByNumber(_ context.Context, number uint64) (*blockHeader, error) {
+	n.calls++
+	for header := n.chain[n.head]; header != nil; header = n.chain[header.parent] {
+		if header.number == number {
+			return header, nil
+		}
+		if header.number < number {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// newForkedChain builds a canonical chain c1..c10 and a fork f6..f8 branching off c5.
+func newForkedChain() fakeChain {
+	chain := fakeChain{"genesis": {number: 0, hash: "genesis"}}
+	chain.extend(chain["genesis"], "c", 10)
+	chain.extend(chain["c5"], "f", 3)
+	return chain
+}
+
+func forkChoiceOf(pod string, header *blockHeader) *ClientForkChoice {
+	return &ClientForkChoice{Pod: &kubernetes.Pod{Name: pod}, BlockNumber: header.number, BlockHash: header.hash}
+}
+
+func TestFindCommonAncestor(t *testing.T) {
+	chain := newForkedChain()
+	ctx := context.Background()
+	type testCase struct {
+		a, b     string
+		expected string
+	}
+	testCases := []testCase{
+		{a: "c10", b: "c10", expected: "c10"},
+		{a: "c10", b: "c7", expected: "c7"},
+		{a: "c3", b: "c10", expected: "c3"},
+		{a: "c10", b: "f8", expected: "c5"},
+		{a: "f6", b: "c9", expected: "c5"},
+		{a: "c1", b: "f8", expected: "c1"},
+	}
+	for _, test := range testCases {
+		ancestor, err := findCommonAncestor(ctx, chain.node(test.a), chain[test.a], chain.node(test.b), chain[test.b])
+		if err != nil {
+			t.Fatalf("%s/%s: %s", test.a, test.b, err)
+		}
+		if ancestor.hash != test.expected {
+			t.Errorf("%s/%s: expected %s, got %s", test.a, test.b, test.expected, ancestor.hash)
+		}
+	}
+
+	// a reader missing part of the chain
+	partial := fakeChain{"f8": chain["f8"]}
+	_, err := findCommonAncestor(ctx, chain.node("c10"), chain["c10"], partial.node("f8"), chain["f8"])
+	if err == nil {
+		t.Fatal("expected a missing block to be an error")
+	}
+}
+
+func TestFindCommonAncestorDepthCap(t *testing.T) {
+	chain := fakeChain{"genesis": {number: 0, hash: "genesis"}}
+	base := chain.extend(chain["genesis"], "c", 10)
+	canonical := chain.extend(base, "a", maxForkWalkDepth)
+	fork := chain.extend(base, "b", maxForkWalkDepth)
+
+	_, err := findCommonAncestor(context.Background(), chain.node(canonical.hash), canonical, chain.node(fork.hash), fork)
+	if err == nil {
+		t.Fatal("expected the walk to give up past the depth cap")
+	}
+
+	// just within the cap
+	canonical = chain.extend(base, "x", maxForkWalkDepth/2)
+	fork = chain.extend(base, "y", maxForkWalkDepth/2)
+	ancestor, err := findCommonAncestor(context.Background(), chain.node(canonical.hash), canonical, chain.node(fork.hash), fork)
+	if err != nil || ancestor.hash != base.hash {
+		t.Fatalf("expected %s to be the common ancestor, got %v %v", base.hash, ancestor, err)
+	}
+}
+
+func TestClassifyFork(t *testing.T) {
+	chain := newForkedChain()
+	ctx := context.Background()
+	canonical := chain["c10"]
+	type testCase struct {
+		name     string
+		node     *ClientForkChoice
+		reader   chainReader
+		status   types.ForkStatus
+		depth    uint64
+		ancestor uint64
+	}
+	testCases := []testCase{
+		{name: "lagging", node: forkChoiceOf("cl-1", chain["c7"]), reader: chain.node("c7"), status: types.ForkStatusLagging, depth: 3, ancestor: 7},
+		{name: "at genesis", node: forkChoiceOf("cl-2", chain["genesis"]), reader: chain.node("genesis"), status: types.ForkStatusLagging, depth: 10},
+		{name: "forked", node: forkChoiceOf("cl-3", chain["f8"]), reader: chain.node("f8"), status: types.ForkStatusForked, depth: 3, ancestor: 5},
+		{name: "head not served", node: forkChoiceOf("cl-4", chain["f8"]), reader: fakeChain{}.node("f8"), status: types.ForkStatusUnreachable},
+		{name: "head not queried", node: &ClientForkChoice{Pod: &kubernetes.Pod{Name: "cl-5"}, BlockNumber: 0, BlockHash: "N/A"}, reader: chain.node("c10"), status: types.ForkStatusUnreachable},
+	}
+
+	for _, test := range testCases {
+		status := classifyFork(ctx, chain.node("c10"), canonical, test.reader, test.node)
+		if status.Status != test.status || status.Depth != test.depth || status.CommonAncestor != test.ancestor {
+			t.Errorf("%s: expected %s depth %d ancestor %d, got %+v", test.name, test.status, test.depth, test.ancestor, status)
+		}
+	}
+
+	// a node ahead of a lagging consensus head
+	status := classifyFork(ctx, chain.node("c6"), chain["c6"], chain.node("c10"), forkChoiceOf("cl-6", chain["c10"]))
+	if status.Status != types.ForkStatusAhead || status.Depth != 4 || status.CommonAncestor != 6 {
+		t.Fatalf("expected the node to be 4 blocks ahead, got %+v", status)
+	}
+}
+
+func TestClassifyForkFarBehind(t *testing.T) {
+	chain := fakeChain{"genesis": {number: 0, hash: "genesis"}}
+	canonical := chain.extend(chain["genesis"], "c", 10*maxForkWalkDepth)
+	ctx := context.Background()
+
+	// a node stuck long before the consensus head is lagging, found without walking the chain
+	canonicalReader := chain.node(canonical.hash)
+	nodeReader := chain.node("c10")
+	status := classifyFork(ctx, canonicalReader, canonical, nodeReader, forkChoiceOf("cl-1", chain["c10"]))
+	if status.Status != types.ForkStatusLagging || status.Depth != canonical.number-10 || status.CommonAncestor != 10 {
+		t.Fatalf("expected the node to be lagging, got %+v", status)
+	}
+	if canonicalReader.calls+nodeReader.calls > 2 {
+		t.Fatalf("expected at most 2 lookups, got %d", canonicalReader.calls+nodeReader.calls)
+	}
+
+	// a fork deeper than the walk limit can't be classified, but isn't reported as forked either
+	fork := chain.extend(chain["c10"], "f", 2*maxForkWalkDepth)
+	status = classifyFork(ctx, chain.node(canonical.hash), canonical, chain.node(fork.hash), forkChoiceOf("cl-2", fork))
+	if status.Status != types.ForkStatusUnknown || status.Error == "" {
+		t.Fatalf("expected the fork to be unknown with an error, got %+v", status)
+	}
+}
+
+func TestClassifyForkEmptySlot(t *testing.T) {
+	// the canonical chain skipped slot 6, the node built its own block at slot 6
+	chain := fakeChain{"genesis": {number: 0, hash: "genesis"}}
+	chain.extend(chain["genesis"], "c", 5)
+	chain["c7"] = &blockHeader{number: 7, hash: "c7", parent: "c5"}
+	chain.extend(chain["c5"], "f", 1)
+
+	status := classifyFork(context.Background(), chain.node("c7"), chain["c7"], chain.node("f6"), forkChoiceOf("cl-1", chain["f6"]))
+	if status.Status != types.ForkStatusForked || status.Depth != 1 || status.CommonAncestor != 5 {
+		t.Fatalf("expected the node to be forked off slot 5, got %+v", status)
+	}
+}
+
+type failingReader struct {
+	*fakeNode
+}
+
+func (r failingReader) headerByNumber(context.Context, uint64) (*blockHeader, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestClassifyForkLookupFails(t *testing.T) {
+	chain := newForkedChain()
+	status := classifyFork(context.Background(), failingReader{chain.node("c10")}, chain["c10"], chain.node("c7"), forkChoiceOf("cl-1", chain["c7"]))
+	if status.Status != types.ForkStatusUnknown || status.Error == "" {
+		t.Fatalf("expected a failed lookup to be unknown, got %+v", status)
+	}
+}
+
+func TestClassifyForks(t *testing.T) {
+	chain := newForkedChain()
+	readers := map[string]chainReader{"cl-1": chain.node("c10"), "cl-2": chain.node("c8"), "cl-3": chain.node("f7")}
+	consensusHead := forkChoiceOf("cl-1", chain["c10"])
+	statuses := classifyForks(context.Background(), readers, consensusHead, []*ClientForkChoice{
+		forkChoiceOf("cl-2", chain["c8"]),
+		forkChoiceOf("cl-3", chain["f7"]),
+	})
+	if len(statuses) != 2 || statuses["cl-2"].Status != types.ForkStatusLagging || statuses["cl-3"].Status != types.ForkStatusForked {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+
+	// without the consensus head nothing can be classified
+	readers["cl-1"] = fakeChain{}.node("c10")
+	statuses = classifyForks(context.Background(), readers, consensusHead, []*ClientForkChoice{forkChoiceOf("cl-2", chain["c8"])})
+	if len(statuses) != 0 {
+		t.Fatalf("expected no statuses, got %+v", statuses)
+	}
+}
+
+func TestDetectReorgs(t *testing.T) {
+	chain := newForkedChain()
+	ctx := context.Background()
+	checker := &EthNetworkChecker{lastHeads: make(map[string]*blockHeader)}
+
+	// the first round only records heads
+	readers := map[string]chainReader{"cl-1": chain.node("f8"), "cl-2": chain.node("c7")}
+	checker.detectReorgs(ctx, readers, []*ClientForkChoice{forkChoiceOf("cl-1", chain["f8"]), forkChoiceOf("cl-2", chain["c7"])})
+	if len(checker.reorgs) != 0 {
+		t.Fatalf("expected no reorgs in the first round, got %+v", checker.reorgs)
+	}
+
+	// cl-1 switches from the fork to the canonical chain, cl-2 advances along it
+	readers = map[string]chainReader{"cl-1": chain.node("c9"), "cl-2": chain.node("c9")}
+	checker.detectReorgs(ctx, readers, []*ClientForkChoice{forkChoiceOf("cl-1", chain["c9"]), forkChoiceOf("cl-2", chain["c9"])})
+	if len(checker.reorgs) != 1 {
+		t.Fatalf("expected one reorg, got %+v", checker.reorgs)
+	}
+	reorg := checker.reorgs[0]
+	if reorg.Pod != "cl-1" || reorg.OldHead != "f8" || reorg.NewHead != "c9" || reorg.Depth != 3 {
+		t.Fatalf("unexpected reorg %+v", reorg)
+	}
+
+	// unchanged heads and unreadable heads are skipped
+	readers = map[string]chainReader{"cl-1": chain.node("c9"), "cl-2": fakeChain{}.node("f6")}
+	checker.detectReorgs(ctx, readers, []*ClientForkChoice{forkChoiceOf("cl-1", chain["c9"]), forkChoiceOf("cl-2", chain["f6"])})
+	if len(checker.reorgs) != 1 || checker.lastHeads["cl-2"].hash != "c9" {
+		t.Fatalf("expected no new reorgs, got %+v", checker.reorgs)
+	}
+}
@@ -3,6 +3,7 @@ package ethereum
 import (
 	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
 	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
 	"context"
 	log "github.com/sirupsen/logrus"
 	"time"
@@ -11,12 +12,39 @@ import "attacknet/cmd/pkg/health/types"
 
 type EthNetworkChecker struct {
 	kubeClient           *kubernetes.KubeClient
+	rpcPool              *RpcPool
 	podsUnderTest        []*chaos_mesh.PodUnderTest
 	podsUnderTestLookup  map[string]*chaos_mesh.PodUnderTest
 	healthCheckStartTime time.Time
+	clientSchema         confTypes.ClientSchema
+	mode                 confTypes.HealthCheckMode
+	minPeers             *int // nil disables the peer and sync checks
+	allowSyncing         bool
+	maxFinalityLagEpochs *int
+	validatorChecks      *confTypes.ValidatorCheckConfig
+	// validator indices held by each node, keyed by node index. nil if unknown
+	validatorRanges map[int]validatorRange
+	// the head each client reported in the previous round, keyed by pod name
+	lastHeads map[string]*blockHeader
+	reorgs    []*types.Reorg
+	// fetched from the beacon spec on first use
+	slotsPerEpoch uint64
+	// pods that replaced a pod under test that was killed, keyed by their name
+	restartedPods map[string]*kubernetes.Pod
 }
 
-func CreateEthNetworkChecker(kubeClient *kubernetes.KubeClient, podsUnderTest []*chaos_mesh.PodUnderTest) *EthNetworkChecker {
+func CreateEthNetworkChecker(
+	kubeClient *kubernetes.KubeClient,
+	rpcPool *RpcPool,
+	podsUnderTest []*chaos_mesh.PodUnderTest,
+	clientSchema confTypes.ClientSchema,
+	healthCheckConfig confTypes.HealthCheckConfig,
+	validatorKeys map[int]int,
+) *EthNetworkChecker {
+	mode := healthCheckConfig.Mode
+	if mode == "" {
+		mode = confTypes.NetworkRecovery
+	}
 	// convert podsUnderTest to a lookup
 	podsUnderTestMap := make(map[string]*chaos_mesh.PodUnderTest)
 
@@ -28,16 +56,26 @@ func CreateEthNetworkChecker(kubeClient *kubernetes.KubeClient, podsUnderTest []
 		podsUnderTest:        podsUnderTest,
 		podsUnderTestLookup:  podsUnderTestMap,
 		kubeClient:           kubeClient,
+		rpcPool:              rpcPool,
 		healthCheckStartTime: time.Now(),
+		clientSchema:         clientSchema,
+		mode:                 mode,
+		minPeers:             healthCheckConfig.MinPeers,
+		allowSyncing:         healthCheckConfig.AllowSyncing,
+		maxFinalityLagEpochs: healthCheckConfig.MaxFinalityLagEpochs,
+		validatorChecks:      healthCheckConfig.ValidatorChecks,
+		validatorRanges:      buildValidatorRanges(validatorKeys),
+		restartedPods:        make(map[string]*kubernetes.Pod),
+		lastHeads:            make(map[string]*blockHeader),
 	}
 }
 
 func (e *EthNetworkChecker) RunAllChecks(ctx context.Context, prevHealthCheckResult *types.HealthCheckResult) (*types.HealthCheckResult, error) {
-	execRpcClients, err := e.dialToExecutionClients(ctx)
+	execRpcClients, execPodsNotRestarted, err := e.dialToExecutionClients(ctx)
 	if err != nil {
 		return nil, err
 	}
-	beaconRpcClients, err := e.dialToBeaconClients(ctx)
+	beaconRpcClients, beaconPodsNotRestarted, err := e.dialToBeaconClients(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +85,15 @@ func (e *EthNetworkChecker) RunAllChecks(ctx context.Context, prevHealthCheckRes
 	if err != nil {
 		return nil, err
 	}
-	latestElArtifact := e.convertResultToArtifact(prevHealthCheckResult.LatestElBlockResult, latestElResult)
+	markPodsNotRestarted(latestElResult, execPodsNotRestarted)
+	latestElArtifact := e.convertResultToArtifact(prevHealthCheckResult.LatestElBlockResult, latestElResult, e.clientSchema.ExecutionLabelValue)
 
 	finalElResult, err := e.getExecBlockConsensus(ctx, execRpcClients, "finalized", 3)
 	if err != nil {
 		return nil, err
 	}
-	finalElArtifact := e.convertResultToArtifact(prevHealthCheckResult.FinalizedElBlockResult, finalElResult)
+	markPodsNotRestarted(finalElResult, execPodsNotRestarted)
+	finalElArtifact := e.convertResultToArtifact(prevHealthCheckResult.FinalizedElBlockResult, finalElResult, e.clientSchema.ExecutionLabelValue)
 
 	log.Debugf("Finalization -> latest lag: %d", latestElResult.ConsensusBlock-finalElResult.ConsensusBlock)
 
@@ -61,27 +101,76 @@ func (e *EthNetworkChecker) RunAllChecks(ctx context.Context, prevHealthCheckRes
 	if err != nil {
 		return nil, err
 	}
-	latestClArtifact := e.convertResultToArtifact(prevHealthCheckResult.LatestClBlockResult, latestClResult)
+	markPodsNotRestarted(latestClResult, beaconPodsNotRestarted)
+	latestClArtifact := e.convertResultToArtifact(prevHealthCheckResult.LatestClBlockResult, latestClResult, e.clientSchema.BeaconLabelValue)
 
 	finalClResult, err := e.getBeaconClientConsensus(ctx, beaconRpcClients, "finalized", 3)
 	if err != nil {
 		return nil, err
 	}
-	finalClArtifact := e.convertResultToArtifact(prevHealthCheckResult.FinalizedClBlockResult, finalClResult)
+	markPodsNotRestarted(finalClResult, beaconPodsNotRestarted)
+	finalClArtifact := e.convertResultToArtifact(prevHealthCheckResult.FinalizedClBlockResult, finalClResult, e.clientSchema.BeaconLabelValue)
+
+	finalityResult, err := e.getFinalityResult(ctx, beaconRpcClients, latestClResult.ConsensusBlock, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	var validatorResult *types.ValidatorResult
+	if e.validatorChecks != nil {
+		canonicalClient := e.canonicalBeaconClient(beaconRpcClients, latestClResult)
+		if canonicalClient == nil {
+			log.Warn("No beacon node is following the consensus head. Skipping validator checks")
+			validatorResult = &types.ValidatorResult{Errors: []string{"no beacon node is following the consensus head"}}
+		} else {
+			validatorResult, err = e.getValidatorResult(ctx, canonicalClient, beaconRpcClients, latestClResult.ConsensusBlock)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var elNodeStatus, clNodeStatus *types.NodeStatusResult
+	if e.minPeers != nil {
+		elNodeStatus = evaluateNodeStatus("EL", *e.minPeers, e.allowSyncing, e.getExecNodeStatus(ctx, execRpcClients))
+		clNodeStatus = evaluateNodeStatus("CL", *e.minPeers, e.allowSyncing, e.getBeaconNodeStatus(ctx, beaconRpcClients))
+	}
 
 	results := &types.HealthCheckResult{
 		LatestElBlockResult:    latestElArtifact,
 		FinalizedElBlockResult: finalElArtifact,
 		LatestClBlockResult:    latestClArtifact,
 		FinalizedClBlockResult: finalClArtifact,
+		ElNodeStatusResult:     elNodeStatus,
+		ClNodeStatusResult:     clNodeStatus,
+		FinalityResult:         finalityResult,
+		ValidatorResult:        validatorResult,
+		Reorgs:                 e.reorgs,
 	}
 
 	return results, nil
 }
 
+// canonicalBeaconClient returns a beacon client whose head matches the consensus head.
+func (e *EthNetworkChecker) canonicalBeaconClient(clients []*BeaconClientRpc, headResult *types.BlockConsensusTestResult) *BeaconClientRpc {
+	for _, client := range clients {
+		name := client.endpoint.Pod.GetName()
+		_, wrongBlock := headResult.FailingClientsReportedBlock[name]
+		_, wrongHash := headResult.FailingClientsReportedHash[name]
+		if !wrongBlock && !wrongHash {
+			return client
+		}
+	}
+	return nil
+}
+
+// convertResultToArtifact records which clients recovered since the previous round. Clients that failed at some point
+// record the time since health checks started. Restarted pods record the time since they restarted instead, whether
+// they failed or not.
 func (e *EthNetworkChecker) convertResultToArtifact(
 	prevArtifact *types.BlockConsensusArtifact,
-	result *types.BlockConsensusTestResult) *types.BlockConsensusArtifact {
+	result *types.BlockConsensusTestResult,
+	clientType string) *types.BlockConsensusArtifact {
 
 	timeSinceChecksStarted := time.Since(e.healthCheckStartTime)
 	recoveredClients := make(map[string]int)
@@ -100,6 +189,20 @@ func (e *EthNetworkChecker) convertResultToArtifact(
 			}
 		}
 
+	}
+
+	for name, pod := range e.restartedPods {
+		if !pod.MatchesLabel(e.clientSchema.ClientTypeLabel, clientType) {
+			continue
+		}
+		_, blockFailing := result.FailingClientsReportedBlock[name]
+		_, hashFailing := result.FailingClientsReportedHash[name]
+		if !blockFailing && !hashFailing {
+			recoveredClients[name] = int(time.Since(pod.StartedAt).Seconds())
+		}
+	}
+
+	if prevArtifact != nil {
 		// merge previously recovered clients with the new
 		for k, v := range prevArtifact.NodeRecoveryTimeSeconds {
 			recoveredClients[k] = v
@@ -108,19 +211,19 @@ func (e *EthNetworkChecker) convertResultToArtifact(
 
 	didUnfaultedNodesNeedToRecover := false
 	for client := range recoveredClients {
-		if _, wasUnderTest := e.podsUnderTestLookup[client]; !wasUnderTest {
+		if !e.isPodUnderTest(client) {
 			didUnfaultedNodesNeedToRecover = true
 		}
 	}
 
 	didUnfaultedNodesFail := false
 	for client := range result.FailingClientsReportedBlock {
-		if _, wasUnderTest := e.podsUnderTestLookup[client]; !wasUnderTest {
+		if !e.isPodUnderTest(client) {
 			didUnfaultedNodesFail = true
 		}
 	}
 	for client := range result.FailingClientsReportedHash {
-		if _, wasUnderTest := e.podsUnderTestLookup[client]; !wasUnderTest {
+		if !e.isPodUnderTest(client) {
 			didUnfaultedNodesFail = true
 		}
 	}
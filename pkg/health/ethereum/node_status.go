@@ -0,0 +1,117 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"context"
+	"fmt"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	nethttp "net/http"
+	"strconv"
+)
+
+func (e *EthNetworkChecker) getExecNodeStatus(ctx context.Context, clients []*ExecClientRPC) map[string]*types.NodeStatus {
+	// GetNodeStatus reports errors in the status, so there's no error to handle
+	results, _ := queryConcurrently(ctx, clients, e.rpcPool.callTimeout, func(ctx context.Context, client *ExecClientRPC) (*types.NodeStatus, error) {
+		return client.GetNodeStatus(ctx), nil
+	})
+	statuses := make(map[string]*types.NodeStatus)
+	for i, client := range clients {
+		statuses[client.endpoint.Pod.GetName()] = results[i]
+	}
+	return statuses
+}
+
+func (e *EthNetworkChecker) getBeaconNodeStatus(ctx context.Context, clients []*BeaconClientRpc) map[string]*types.NodeStatus {
+	results, _ := queryConcurrently(ctx, clients, e.rpcPool.callTimeout, func(ctx context.Context, client *BeaconClientRpc) (*types.NodeStatus, error) {
+		return client.GetNodeStatus(ctx), nil
+	})
+	statuses := make(map[string]*types.NodeStatus)
+	for i, client := range clients {
+		statuses[client.endpoint.Pod.GetName()] = results[i]
+	}
+	return statuses
+}
+
+// evaluateNodeStatus fails clients that have fewer than minPeers peers, are syncing when that isn't allowed, or
+// couldn't be queried.
+func evaluateNodeStatus(clientType string, minPeers int, allowSyncing bool, statuses map[string]*types.NodeStatus) *types.NodeStatusResult {
+	result := &types.NodeStatusResult{
+		MinPeers:       minPeers,
+		AllowSyncing:   allowSyncing,
+		FailingClients: make(map[string]*types.NodeStatus),
+	}
+	for pod, status := range statuses {
+		tooFewPeers := status.PeerCount < uint64(minPeers)
+		syncing := status.Syncing && !allowSyncing
+		if status.Error != "" || tooFewPeers || syncing {
+			result.FailingClients[pod] = status
+			log.Warnf("---> %s client %s peers: %d syncing: %t sync distance: %d %s", clientType, pod, status.PeerCount, status.Syncing, status.SyncDistance, status.Error)
+		}
+	}
+	return result
+}
+
+func (c *ExecClientRPC) GetNodeStatus(ctx context.Context) *types.NodeStatus {
+	status := &types.NodeStatus{}
+	peers, err := c.client.PeerCount(ctx)
+	if err != nil {
+		status.Error = fmt.Sprintf("net_peerCount failed: %s", err)
+		return status
+	}
+	status.PeerCount = peers
+
+	progress, err := c.client.SyncProgress(ctx)
+	if err != nil {
+		status.Error = fmt.Sprintf("eth_syncing failed: %s", err)
+		return status
+	}
+	// eth_syncing returns false once the client is synced
+	if progress != nil {
+		status.Syncing = true
+		if progress.HighestBlock > progress.CurrentBlock {
+			status.SyncDistance = progress.HighestBlock - progress.CurrentBlock
+		}
+	}
+	return status
+}
+
+func (c *BeaconClientRpc) GetNodeStatus(ctx context.Context) *types.NodeStatus {
+	status := &types.NodeStatus{}
+	peers, err := c.getPeerCount(ctx)
+	if err != nil {
+		status.Error = fmt.Sprintf("/eth/v1/node/peer_count failed: %s", err)
+		return status
+	}
+	status.PeerCount = peers
+
+	syncState, err := c.client.NodeSyncing(ctx, &api.NodeSyncingOpts{})
+	if err != nil {
+		status.Error = fmt.Sprintf("/eth/v1/node/syncing failed: %s", err)
+		return status
+	}
+	status.Syncing = syncState.Data.IsSyncing
+	status.SyncDistance = uint64(syncState.Data.SyncDistance)
+	return status
+}
+
+type peerCountResponse struct {
+	Data struct {
+		Connected string `json:"connected"`
+	} `json:"data"`
+}
+
+// getPeerCount queries /eth/v1/node/peer_count, which go-eth2-client doesn't support.
+func (c *BeaconClientRpc) getPeerCount(ctx context.Context) (uint64, error) {
+	var body peerCountResponse
+	err := c.callJson(ctx, nethttp.MethodGet, "/eth/v1/node/peer_count", nil, &body)
+	if err != nil {
+		return 0, err
+	}
+	peers, err := strconv.ParseUint(body.Data.Connected, 10, 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "invalid peer count '%s' from %s", body.Data.Connected, c.endpoint.Pod.GetName())
+	}
+	return peers, nil
+}
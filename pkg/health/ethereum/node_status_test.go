@@ -0,0 +1,191 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/ethereum/go-ethereum/ethclient"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateNodeStatus(t *testing.T) {
+	statuses := map[string]*types.NodeStatus{
+		"el-1": {PeerCount: 3},
+		"el-2": {PeerCount: 1},
+		"el-3": {PeerCount: 0},
+		"el-4": {PeerCount: 5, Syncing: true, SyncDistance: 12},
+		"el-5": {Error: "net_peerCount failed: connection refused"},
+	}
+
+	type testCase struct {
+		name         string
+		minPeers     int
+		allowSyncing bool
+		failing      []string
+	}
+	testCases := []testCase{
+		{name: "no peers required", minPeers: 0, failing: []string{"el-4", "el-5"}},
+		{name: "one peer", minPeers: 1, failing: []string{"el-3", "el-4", "el-5"}},
+		{name: "threshold is inclusive", minPeers: 3, failing: []string{"el-2", "el-3", "el-4", "el-5"}},
+		{name: "syncing allowed", minPeers: 1, allowSyncing: true, failing: []string{"el-3", "el-5"}},
+		// a client that couldn't be queried always fails
+		{name: "errors fail", minPeers: 0, allowSyncing: true, failing: []string{"el-5"}},
+	}
+	for _, test := range testCases {
+		result := evaluateNodeStatus("EL", test.minPeers, test.allowSyncing, statuses)
+		if result.MinPeers != test.minPeers || result.AllowSyncing != test.allowSyncing {
+			t.Errorf("%s: expected the thresholds to be recorded, got %+v", test.name, result)
+		}
+		if len(result.FailingClients) != len(test.failing) {
+			t.Errorf("%s: expected %v to fail, got %v", test.name, test.failing, result.FailingClients)
+			continue
+		}
+		for _, pod := range test.failing {
+			if result.FailingClients[pod] != statuses[pod] {
+				t.Errorf("%s: expected %s to fail", test.name, pod)
+			}
+		}
+	}
+}
+
+// jsonRpcServer answers JSON-RPC calls with the result, or error, registered for the method.
+func jsonRpcServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		var req struct {
+			Id     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		result, ok := results[req.Method]
+		if !ok {
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, req.Id)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.Id, result)
+	}))
+}
+
+func TestExecGetNodeStatus(t *testing.T) {
+	type testCase struct {
+		name     string
+		results  map[string]string
+		expected types.NodeStatus
+		error    string
+	}
+	testCases := []testCase{
+		{name: "synced", results: map[string]string{"net_peerCount": `"0x5"`, "eth_syncing": `false`}, expected: types.NodeStatus{PeerCount: 5}},
+		{
+			name:     "syncing",
+			results:  map[string]string{"net_peerCount": `"0x2"`, "eth_syncing": `{"startingBlock":"0x0","currentBlock":"0x10","highestBlock":"0x30"}`},
+			expected: types.NodeStatus{PeerCount: 2, Syncing: true, SyncDistance: 32},
+		},
+		{name: "peer count unsupported", results: map[string]string{"eth_syncing": `false`}, error: "net_peerCount failed"},
+		{name: "syncing unsupported", results: map[string]string{"net_peerCount": `"0x1"`}, error: "eth_syncing failed"},
+	}
+	for _, test := range testCases {
+		server := jsonRpcServer(t, test.results)
+		client, err := ethclient.Dial(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := (&ExecClientRPC{client: client, callTimeout: time.Second}).GetNodeStatus(context.Background())
+		if test.error != "" {
+			if !strings.Contains(status.Error, test.error) {
+				t.Errorf("%s: expected an error containing '%s', got %+v", test.name, test.error, status)
+			}
+		} else if *status != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, status)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+// fakeSyncingClient only implements /eth/v1/node/syncing.
+type fakeSyncingClient struct {
+	beaconApiClient
+	state *apiv1.SyncState
+}
+
+func (c *fakeSyncingClient) NodeSyncing(context.Context, *api.NodeSyncingOpts) (*api.Response[*apiv1.SyncState], error) {
+	return &api.Response[*apiv1.SyncState]{Data: c.state}, nil
+}
+
+func peerCountServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path != "/eth/v1/node/peer_count" {
+			w.WriteHeader(nethttp.StatusNotFound)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func newTestBeaconClient(server *httptest.Server, state *apiv1.SyncState) *BeaconClientRpc {
+	return &BeaconClientRpc{
+		endpoint:    &kubernetes.PodEndpoint{Pod: &kubernetes.Pod{Name: "cl-1"}},
+		client:      &fakeSyncingClient{state: state},
+		address:     server.URL,
+		httpClient:  server.Client(),
+		callTimeout: time.Second,
+	}
+}
+
+func TestGetPeerCount(t *testing.T) {
+	type testCase struct {
+		name        string
+		status      int
+		body        string
+		expected    uint64
+		expectError bool
+	}
+	testCases := []testCase{
+		{name: "peers", status: nethttp.StatusOK, body: `{"data":{"connected":"12","disconnected":"3"}}`, expected: 12},
+		{name: "no peers", status: nethttp.StatusOK, body: `{"data":{"connected":"0"}}`, expected: 0},
+		{name: "not a number", status: nethttp.StatusOK, body: `{"data":{"connected":"lots"}}`, expectError: true},
+		{name: "missing count", status: nethttp.StatusOK, body: `{"data":{}}`, expectError: true},
+		{name: "invalid json", status: nethttp.StatusOK, body: `{"data":`, expectError: true},
+		{name: "server error", status: nethttp.StatusInternalServerError, body: `{}`, expectError: true},
+	}
+	for _, test := range testCases {
+		server := peerCountServer(test.status, test.body)
+		peers, err := newTestBeaconClient(server, nil).getPeerCount(context.Background())
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+		} else if err != nil || peers != test.expected {
+			t.Errorf("%s: expected %d peers, got %d %v", test.name, test.expected, peers, err)
+		}
+		server.Close()
+	}
+}
+
+func TestBeaconGetNodeStatus(t *testing.T) {
+	server := peerCountServer(nethttp.StatusOK, `{"data":{"connected":"4"}}`)
+	defer server.Close()
+	status := newTestBeaconClient(server, &apiv1.SyncState{IsSyncing: true, SyncDistance: 7}).GetNodeStatus(context.Background())
+	expected := types.NodeStatus{PeerCount: 4, Syncing: true, SyncDistance: 7}
+	if *status != expected {
+		t.Fatalf("expected %+v, got %+v", expected, status)
+	}
+
+	failing := peerCountServer(nethttp.StatusServiceUnavailable, `{}`)
+	defer failing.Close()
+	status = newTestBeaconClient(failing, &apiv1.SyncState{}).GetNodeStatus(context.Background())
+	if !strings.Contains(status.Error, "/eth/v1/node/peer_count failed") {
+		t.Fatalf("expected the peer count error to be recorded, got %+v", status)
+	}
+}
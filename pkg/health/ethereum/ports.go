@@ -0,0 +1,42 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"sort"
+	"strings"
+)
+
+// resolveClientPort picks the port to reach a client's API on. Per-client overrides win, then the named container
+// port of the pod, then the default.
+func resolveClientPort(pod kubernetes.KubePod, ports confTypes.ClientPorts, clientNameLabel string) int {
+	if port, ok := clientPortOverride(pod, ports.Clients, clientNameLabel); ok {
+		return port
+	}
+	if livePod, ok := pod.(*kubernetes.Pod); ok && ports.PortName != "" {
+		if port, exists := livePod.ContainerPorts[ports.PortName]; exists {
+			return port
+		}
+	}
+	return ports.Default
+}
+
+func clientPortOverride(pod kubernetes.KubePod, clients map[string]int, clientNameLabel string) (int, bool) {
+	if clientName, exists := pod.GetLabels()[clientNameLabel]; exists {
+		port, ok := clients[clientName]
+		return port, ok
+	}
+
+	// pods without the client name label fall back to matching the pod name. sorted so the result is stable.
+	var names []string
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.Contains(pod.GetName(), name) {
+			return clients[name], true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,74 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"testing"
+)
+
+const testClientNameLabel = "client-name"
+
+func TestResolveClientPort(t *testing.T) {
+	ports := confTypes.ClientPorts{
+		Clients:  map[string]int{"prysm": 3500, "nimbus": 5052},
+		PortName: "http",
+		Default:  4000,
+	}
+	type testCase struct {
+		name     string
+		pod      *kubernetes.Pod
+		ports    confTypes.ClientPorts
+		expected int
+	}
+	testCases := []testCase{
+		{
+			name:     "client override from the label wins over the named port",
+			pod:      &kubernetes.Pod{Name: "cl-1-prysm-geth", Labels: map[string]string{testClientNameLabel: "prysm"}, ContainerPorts: map[string]int{"http": 4001}},
+			ports:    ports,
+			expected: 3500,
+		},
+		{
+			name:     "labelled client without an override uses the named port",
+			pod:      &kubernetes.Pod{Name: "cl-2-prysm-geth", Labels: map[string]string{testClientNameLabel: "lighthouse"}, ContainerPorts: map[string]int{"http": 4001}},
+			ports:    ports,
+			expected: 4001,
+		},
+		{
+			name:     "the label takes precedence over the pod name",
+			pod:      &kubernetes.Pod{Name: "cl-3-nimbus-geth", Labels: map[string]string{testClientNameLabel: "lighthouse"}},
+			ports:    ports,
+			expected: 4000,
+		},
+		{
+			name:     "unlabelled pods match overrides by name",
+			pod:      &kubernetes.Pod{Name: "cl-4-nimbus-geth", ContainerPorts: map[string]int{"http": 4001}},
+			ports:    ports,
+			expected: 5052,
+		},
+		{
+			name:     "named port without an override",
+			pod:      &kubernetes.Pod{Name: "cl-5-teku-geth", ContainerPorts: map[string]int{"http": 4001, "metrics": 8008}},
+			ports:    ports,
+			expected: 4001,
+		},
+		{
+			name:     "pod without the named port uses the default",
+			pod:      &kubernetes.Pod{Name: "cl-6-teku-geth", ContainerPorts: map[string]int{"metrics": 8008}},
+			ports:    ports,
+			expected: 4000,
+		},
+		{
+			name:     "no port name configured uses the default",
+			pod:      &kubernetes.Pod{Name: "cl-7-teku-geth", ContainerPorts: map[string]int{"http": 4001}},
+			ports:    confTypes.ClientPorts{Default: 4000},
+			expected: 4000,
+		},
+	}
+
+	for _, test := range testCases {
+		port := resolveClientPort(test.pod, test.ports, testClientNameLabel)
+		if port != test.expected {
+			t.Errorf("%s: expected port %d, got %d", test.name, test.expected, port)
+		}
+	}
+}
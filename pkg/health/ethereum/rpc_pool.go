@@ -0,0 +1,188 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"context"
+	"errors"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultRpcCallTimeout = 10 * time.Second
+
+// RpcPool keeps the port-forwards and RPC clients to every EL and CL client open for the whole suite run, so health
+// checks don't open new tunnels every round. Clients are reconnected when their port-forward dies, their pod restarts,
+// or a call through them failed with a transport error or timed out.
+// Outside of port-forward access mode, clients dial the pods directly and no port-forwards are opened.
+type RpcPool struct {
+	kubeClient   *kubernetes.KubeClient
+	clientSchema confTypes.ClientSchema
+	accessMode   confTypes.AccessMode
+	callTimeout  time.Duration
+
+	mu     sync.Mutex
+	exec   map[string]*ExecClientRPC
+	beacon map[string]*BeaconClientRpc
+}
+
+func CreateRpcPool(kubeClient *kubernetes.KubeClient, clientSchema confTypes.ClientSchema, accessMode confTypes.AccessMode, callTimeout time.Duration) *RpcPool {
+	if callTimeout <= 0 {
+		callTimeout = defaultRpcCallTimeout
+	}
+	return &RpcPool{
+		kubeClient:   kubeClient,
+		clientSchema: clientSchema,
+		accessMode:   accessMode,
+		callTimeout:  callTimeout,
+		exec:         make(map[string]*ExecClientRPC),
+		beacon:       make(map[string]*BeaconClientRpc),
+	}
+}
+
+// ExecClients returns a connected RPC client for each pod.
+func (p *RpcPool) ExecClients(ctx context.Context, pods []kubernetes.KubePod) ([]*ExecClientRPC, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clients := make([]*ExecClientRPC, len(pods))
+	for i, pod := range pods {
+		client, exists := p.exec[pod.GetName()]
+		if !exists || client.stale.Load() || needsReconnect(client.endpoint, pod) {
+			if exists {
+				log.Debugf("Reconnecting to %s", pod.GetName())
+				client.Close()
+			}
+			endpoint, err := p.openEndpoint(pod, p.clientSchema.ExecutionRpcPort)
+			if err != nil {
+				return nil, err
+			}
+			client, err = dialExecRpcClient(endpoint, p.callTimeout)
+			if err != nil {
+				endpoint.Close()
+				return nil, err
+			}
+			p.exec[pod.GetName()] = client
+		}
+		clients[i] = client
+	}
+	return clients, nil
+}
+
+// BeaconClients returns a connected beacon API client for each pod.
+func (p *RpcPool) BeaconClients(ctx context.Context, pods []kubernetes.KubePod) ([]*BeaconClientRpc, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clients := make([]*BeaconClientRpc, len(pods))
+	for i, pod := range pods {
+		client, exists := p.beacon[pod.GetName()]
+		if !exists || client.stale.Load() || needsReconnect(client.endpoint, pod) {
+			if exists {
+				log.Debugf("Reconnecting to %s", pod.GetName())
+				client.Close()
+			}
+			endpoint, err := p.openEndpoint(pod, p.clientSchema.BeaconApiPort)
+			if err != nil {
+				return nil, err
+			}
+			client, err = dialBeaconRpcClient(ctx, endpoint, p.callTimeout)
+			if err != nil {
+				endpoint.Close()
+				return nil, err
+			}
+			p.beacon[pod.GetName()] = client
+		}
+		clients[i] = client
+	}
+	return clients, nil
+}
+
+func (p *RpcPool) openEndpoint(pod kubernetes.KubePod, ports confTypes.ClientPorts) (*kubernetes.PodEndpoint, error) {
+	// kurtosis names the service of each node after its service id
+	serviceName, ok := pod.GetLabels()[p.clientSchema.ServiceIdLabel]
+	if !ok {
+		serviceName = pod.GetName()
+	}
+	return p.kubeClient.OpenEndpoint(pod, serviceName, resolveClientPort(pod, ports, p.clientSchema.ClientNameLabel), p.accessMode)
+}
+
+// needsReconnect returns whether the port-forward died or the pod it points to has restarted since it was opened.
+func needsReconnect(endpoint *kubernetes.PodEndpoint, pod kubernetes.KubePod) bool {
+	if !endpoint.Alive() {
+		return true
+	}
+	oldPod, oldIsLive := endpoint.Pod.(*kubernetes.Pod)
+	newPod, newIsLive := pod.(*kubernetes.Pod)
+	return oldIsLive && newIsLive && (!oldPod.StartedAt.Equal(newPod.StartedAt) || oldPod.IP != newPod.IP)
+}
+
+// Close closes every port-forward and client in the pool.
+func (p *RpcPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, client := range p.exec {
+		client.Close()
+		delete(p.exec, name)
+	}
+	for name, client := range p.beacon {
+		client.Close()
+		delete(p.beacon, name)
+	}
+}
+
+// staleMarker is implemented by clients the pool redials once they're marked stale.
+type staleMarker interface {
+	markStale()
+}
+
+// isTransportError returns whether the error means the connection to the client is broken, rather than the client
+// answering with an error.
+func isTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	cause := stacktrace.RootCause(err)
+	var netErr net.Error
+	return errors.Is(cause, context.DeadlineExceeded) ||
+		errors.Is(cause, io.EOF) ||
+		errors.Is(cause, io.ErrUnexpectedEOF) ||
+		errors.Is(cause, syscall.ECONNREFUSED) ||
+		errors.Is(cause, syscall.ECONNRESET) ||
+		errors.As(cause, &netErr)
+}
+
+// queryConcurrently runs the query against every client at once. Each call gets its own timeout. Results are in the
+// same order as the clients. Clients whose call timed out or failed with a transport error are marked stale, so the
+// pool redials them on the next round.
+func queryConcurrently[C any, R any](ctx context.Context, clients []C, timeout time.Duration, query func(context.Context, C) (R, error)) ([]R, error) {
+	results := make([]R, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client C) {
+			defer wg.Done()
+			callCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i], errs[i] = query(callCtx, client)
+			timedOut := errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+			if marker, ok := any(client).(staleMarker); ok && (timedOut || isTransportError(errs[i])) {
+				marker.markStale()
+			}
+		}(i, client)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,158 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/kubernetes"
+	"context"
+	"errors"
+	"github.com/kurtosis-tech/stacktrace"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type fakeRpcClient struct {
+	id    int
+	delay time.Duration
+	err   error
+	stale atomic.Bool
+}
+
+func (c *fakeRpcClient) markStale() {
+	c.stale.Store(true)
+}
+
+func (c *fakeRpcClient) query(ctx context.Context) (int, error) {
+	select {
+	case <-time.After(c.delay):
+		return c.id, c.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func queryFakeClients(ctx context.Context, clients []*fakeRpcClient, timeout time.Duration) ([]int, error) {
+	return queryConcurrently(ctx, clients, timeout, func(ctx context.Context, client *fakeRpcClient) (int, error) {
+		return client.query(ctx)
+	})
+}
+
+func TestQueryConcurrentlyKeepsOrder(t *testing.T) {
+	// later clients answer first
+	clients := []*fakeRpcClient{{id: 1, delay: 30 * time.Millisecond}, {id: 2, delay: 20 * time.Millisecond}, {id: 3}}
+	results, err := queryFakeClients(context.Background(), clients, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result != i+1 {
+			t.Fatalf("expected results in client order, got %v", results)
+		}
+	}
+	for _, client := range clients {
+		if client.stale.Load() {
+			t.Fatalf("expected client %d not to be marked stale", client.id)
+		}
+	}
+}
+
+func TestQueryConcurrentlyTimeoutPerCall(t *testing.T) {
+	// each call gets the full timeout, so calls running at the same time don't eat into each other's timeout
+	clients := []*fakeRpcClient{{id: 1, delay: 60 * time.Millisecond}, {id: 2, delay: 60 * time.Millisecond}, {id: 3, delay: 60 * time.Millisecond}}
+	start := time.Now()
+	_, err := queryFakeClients(context.Background(), clients, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected every call to finish within its own timeout, got %s", err)
+	}
+	if time.Since(start) > 90*time.Millisecond*2 {
+		t.Fatalf("expected the calls to run concurrently, took %s", time.Since(start))
+	}
+
+	// a call that exceeds the timeout fails the query and marks its client stale
+	clients = []*fakeRpcClient{{id: 1}, {id: 2, delay: time.Second}}
+	_, err = queryFakeClients(context.Background(), clients, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if clients[0].stale.Load() || !clients[1].stale.Load() {
+		t.Fatal("expected only the slow client to be marked stale")
+	}
+}
+
+func TestQueryConcurrentlyMarksStale(t *testing.T) {
+	refused := &url.Error{Op: "Post", URL: "http://localhost:1", Err: syscall.ECONNREFUSED}
+	clients := []*fakeRpcClient{
+		{id: 1, err: stacktrace.Propagate(refused, "unable to reach the client")},
+		{id: 2, err: stacktrace.NewError("block not found")},
+		{id: 3},
+	}
+	_, err := queryFakeClients(context.Background(), clients, time.Second)
+	if err == nil {
+		t.Fatal("expected the query to fail")
+	}
+	if !clients[0].stale.Load() {
+		t.Fatal("expected a transport error to mark the client stale")
+	}
+	if clients[1].stale.Load() || clients[2].stale.Load() {
+		t.Fatal("expected errors from the client itself not to mark it stale")
+	}
+
+}
+
+func TestNeedsReconnect(t *testing.T) {
+	started := time.Unix(1700000000, 0)
+	pod := &kubernetes.Pod{Name: "cl-1", IP: "10.0.0.1", StartedAt: started}
+	endpoint := &kubernetes.PodEndpoint{Pod: pod, Address: "10.0.0.1:4000"}
+
+	type testCase struct {
+		name     string
+		pod      kubernetes.KubePod
+		expected bool
+	}
+	testCases := []testCase{
+		{name: "same pod", pod: &kubernetes.Pod{Name: "cl-1", IP: "10.0.0.1", StartedAt: started}, expected: false},
+		{name: "restarted", pod: &kubernetes.Pod{Name: "cl-1", IP: "10.0.0.1", StartedAt: started.Add(time.Minute)}, expected: true},
+		{name: "rescheduled", pod: &kubernetes.Pod{Name: "cl-1", IP: "10.0.0.2", StartedAt: started}, expected: true},
+		// pods that aren't live pods carry no start time or IP to compare
+		{name: "not a live pod", pod: &fakeKubePod{name: "cl-1"}, expected: false},
+	}
+	for _, test := range testCases {
+		if needsReconnect(endpoint, test.pod) != test.expected {
+			t.Errorf("%s: expected needsReconnect to be %v", test.name, test.expected)
+		}
+	}
+}
+
+type fakeKubePod struct {
+	name string
+}
+
+func (p *fakeKubePod) GetName() string {
+	return p.name
+}
+
+func (p *fakeKubePod) GetLabels() map[string]string {
+	return nil
+}
+
+func (p *fakeKubePod) MatchesLabel(string, string) bool {
+	return false
+}
+
+func TestIsTransportError(t *testing.T) {
+	transport := []error{
+		context.DeadlineExceeded,
+		stacktrace.Propagate(context.DeadlineExceeded, "call timed out"),
+		&url.Error{Op: "Get", URL: "http://localhost:1", Err: syscall.ECONNRESET},
+		syscall.ECONNREFUSED,
+	}
+	for _, err := range transport {
+		if !isTransportError(err) {
+			t.Errorf("expected %v to be a transport error", err)
+		}
+	}
+	if isTransportError(nil) || isTransportError(stacktrace.NewError("block not found")) {
+		t.Fatal("expected client errors not to be transport errors")
+	}
+}
@@ -2,38 +2,113 @@ package ethereum
 
 import (
 	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
+	"attacknet/cmd/pkg/health/types"
 	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
 	"context"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
 )
 
-func getPodsToHealthCheck(
-	ctx context.Context,
-	kubeClient *kubernetes.KubeClient,
-	podsUnderTest []*chaos_mesh.PodUnderTest,
-	podsUnderTestLookup map[string]*chaos_mesh.PodUnderTest,
-	labelKey, labelValue string,
-) ([]kubernetes.KubePod, error) {
+// getPodsToHealthCheck returns the live pods matching the label that should be health checked, along with the names
+// of restarted pods under test that haven't come back yet. Restarted pods are only checked in node recovery mode.
+func (e *EthNetworkChecker) getPodsToHealthCheck(ctx context.Context, labelKey, labelValue string) ([]kubernetes.KubePod, []string, error) {
+	livePods, err := e.kubeClient.PodsMatchingLabel(ctx, labelKey, labelValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	livePodLookup := make(map[string]kubernetes.KubePod)
+	for _, pod := range livePods {
+		livePodLookup[pod.GetName()] = pod
+	}
 
 	var podsToHealthCheck []kubernetes.KubePod
+	var podsNotRestarted []string
+	checked := make(map[string]bool)
 	// add pods under test that match the label criteria _and_ aren't expected to die
-	// todo: depending on whether we're testing network recovery or node recovery, we may want to health check nodes we're expecting to die
-	for _, pod := range podsUnderTest {
-		if pod.MatchesLabel(labelKey, labelValue) && !pod.ExpectDeath {
+	for _, pod := range e.podsUnderTest {
+		if !pod.MatchesLabel(labelKey, labelValue) {
+			continue
+		}
+		if pod.ExpectDeath {
+			if e.mode != confTypes.NodeRecovery {
+				continue
+			}
+			restarted, found := e.resolveRestartedPod(pod, livePods)
+			if !found {
+				log.Debugf("Pod %s has not restarted yet", pod.Name)
+				podsNotRestarted = append(podsNotRestarted, pod.Name)
+				continue
+			}
+			e.restartedPods[restarted.Name] = restarted
+			podsToHealthCheck = append(podsToHealthCheck, restarted)
+			checked[restarted.Name] = true
+			continue
+		}
+		// prefer the live pod so its container ports are known
+		if livePod, exists := livePodLookup[pod.Name]; exists {
+			podsToHealthCheck = append(podsToHealthCheck, livePod)
+		} else {
 			podsToHealthCheck = append(podsToHealthCheck, pod)
 		}
+		checked[pod.Name] = true
 	}
 
 	// add pods that were not targeted by a fault
-	bystanders, err := kubeClient.PodsMatchingLabel(ctx, labelKey, labelValue)
-	if err != nil {
-		return nil, err
-	}
-	for _, pod := range bystanders {
-		_, match := podsUnderTestLookup[pod.GetName()]
+	for _, pod := range livePods {
+		_, match := e.podsUnderTestLookup[pod.GetName()]
 		// don't add pods we've already added
-		if !match {
+		if !match && !checked[pod.GetName()] {
 			podsToHealthCheck = append(podsToHealthCheck, pod)
 		}
 	}
-	return podsToHealthCheck, nil
+	return podsToHealthCheck, podsNotRestarted, nil
+}
+
+// resolveRestartedPod finds the live, ready pod that replaced a killed pod. Killed pods may come back under a new name,
+// so they're matched by their service id label.
+func (e *EthNetworkChecker) resolveRestartedPod(pod *chaos_mesh.PodUnderTest, livePods []kubernetes.KubePod) (*kubernetes.Pod, bool) {
+	serviceId, hasServiceId := pod.Labels[e.clientSchema.ServiceIdLabel]
+	for _, kubePod := range livePods {
+		livePod, ok := kubePod.(*kubernetes.Pod)
+		if !ok || !livePod.Ready {
+			continue
+		}
+		if livePod.Name == pod.Name || (hasServiceId && livePod.MatchesLabel(e.clientSchema.ServiceIdLabel, serviceId)) {
+			return livePod, true
+		}
+	}
+	return nil, false
+}
+
+// isPodUnderTest returns whether the pod was targeted by a fault, including pods that replaced a killed target.
+func (e *EthNetworkChecker) isPodUnderTest(podName string) bool {
+	if _, underTest := e.podsUnderTestLookup[podName]; underTest {
+		return true
+	}
+	_, restarted := e.restartedPods[podName]
+	return restarted
+}
+
+// markPodsNotRestarted fails the check for restarted pods that couldn't be queried because they haven't come back.
+func markPodsNotRestarted(result *types.BlockConsensusTestResult, podsNotRestarted []string) {
+	for _, podName := range podsNotRestarted {
+		result.FailingClientsReportedBlock[podName] = 0
+		result.FailingClientsReportedHash[podName] = "N/A"
+	}
+}
+
+// NodeIndexFromPodName extracts the participant index from pod names created by the ethereum-package, such as
+// cl-03-lighthouse-geth.
+func NodeIndexFromPodName(podName string) (int, bool) {
+	parts := strings.Split(podName, "-")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
 }
@@ -0,0 +1,102 @@
+package ethereum
+
+import (
+	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"testing"
+)
+
+const testServiceIdLabel = "service-id"
+
+func TestResolveRestartedPod(t *testing.T) {
+	checker := &EthNetworkChecker{clientSchema: confTypes.ClientSchema{ServiceIdLabel: testServiceIdLabel}}
+	killed := &chaos_mesh.PodUnderTest{Name: "cl-1-lighthouse-geth-abc", Labels: map[string]string{testServiceIdLabel: "cl-1"}, ExpectDeath: true}
+	unlabelled := &chaos_mesh.PodUnderTest{Name: "cl-2-lighthouse-geth-abc", ExpectDeath: true}
+
+	type testCase struct {
+		name     string
+		pod      *chaos_mesh.PodUnderTest
+		livePods []kubernetes.KubePod
+		expected string
+	}
+	testCases := []testCase{
+		{
+			name:     "restarted under the same name",
+			pod:      killed,
+			livePods: []kubernetes.KubePod{&kubernetes.Pod{Name: "cl-1-lighthouse-geth-abc", Ready: true}},
+			expected: "cl-1-lighthouse-geth-abc",
+		},
+		{
+			name: "replaced under a new name",
+			pod:  killed,
+			livePods: []kubernetes.KubePod{
+				&kubernetes.Pod{Name: "cl-3-lighthouse-geth-xyz", Labels: map[string]string{testServiceIdLabel: "cl-3"}, Ready: true},
+				&kubernetes.Pod{Name: "cl-1-lighthouse-geth-def", Labels: map[string]string{testServiceIdLabel: "cl-1"}, Ready: true},
+			},
+			expected: "cl-1-lighthouse-geth-def",
+		},
+		{
+			name:     "replacement not ready yet",
+			pod:      killed,
+			livePods: []kubernetes.KubePod{&kubernetes.Pod{Name: "cl-1-lighthouse-geth-def", Labels: map[string]string{testServiceIdLabel: "cl-1"}}},
+		},
+		{
+			name:     "pods under test aren't live pods",
+			pod:      killed,
+			livePods: []kubernetes.KubePod{killed},
+		},
+		{
+			name:     "without a service id only the name matches",
+			pod:      unlabelled,
+			livePods: []kubernetes.KubePod{&kubernetes.Pod{Name: "cl-2-lighthouse-geth-def", Labels: map[string]string{testServiceIdLabel: "cl-2"}, Ready: true}},
+		},
+	}
+
+	for _, test := range testCases {
+		pod, found := checker.resolveRestartedPod(test.pod, test.livePods)
+		if test.expected == "" {
+			if found {
+				t.Errorf("%s: expected no replacement, got %s", test.name, pod.Name)
+			}
+			continue
+		}
+		if !found || pod.Name != test.expected {
+			t.Errorf("%s: expected %s, got %v", test.name, test.expected, pod)
+		}
+	}
+}
+
+func TestMarkPodsNotRestarted(t *testing.T) {
+	result := &types.BlockConsensusTestResult{
+		ConsensusBlock:              10,
+		FailingClientsReportedBlock: map[string]uint64{"el-2-geth-lighthouse": 9},
+		FailingClientsReportedHash:  map[string]string{"el-2-geth-lighthouse": "0xb"},
+	}
+	markPodsNotRestarted(result, []string{"el-3-geth-lighthouse", "el-4-geth-lighthouse"})
+
+	if len(result.FailingClientsReportedBlock) != 3 || len(result.FailingClientsReportedHash) != 3 {
+		t.Fatalf("expected both pods to be added to the failing clients, got %v", result.FailingClientsReportedBlock)
+	}
+	if result.FailingClientsReportedBlock["el-3-geth-lighthouse"] != 0 || result.FailingClientsReportedHash["el-4-geth-lighthouse"] != "N/A" {
+		t.Fatalf("unexpected failing clients %v %v", result.FailingClientsReportedBlock, result.FailingClientsReportedHash)
+	}
+	if result.FailingClientsReportedBlock["el-2-geth-lighthouse"] != 9 {
+		t.Fatal("expected existing failures to be kept")
+	}
+}
+
+func TestIsPodUnderTest(t *testing.T) {
+	target := &chaos_mesh.PodUnderTest{Name: "cl-1-lighthouse-geth-abc"}
+	checker := &EthNetworkChecker{
+		podsUnderTestLookup: map[string]*chaos_mesh.PodUnderTest{target.Name: target},
+		restartedPods:       map[string]*kubernetes.Pod{"cl-1-lighthouse-geth-def": {Name: "cl-1-lighthouse-geth-def"}},
+	}
+	if !checker.isPodUnderTest("cl-1-lighthouse-geth-abc") || !checker.isPodUnderTest("cl-1-lighthouse-geth-def") {
+		t.Fatal("expected targets and their replacements to be under test")
+	}
+	if checker.isPodUnderTest("cl-2-lighthouse-geth-abc") {
+		t.Fatal("expected a bystander not to be under test")
+	}
+}
@@ -0,0 +1,294 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	nethttp "net/http"
+	"sort"
+	"strconv"
+)
+
+const defaultProposalWindowSlots = 32
+
+// participationUnavailable is the participation source when neither a lighthouse node nor the validators of each node
+// are known. The participation threshold isn't checked in that case.
+const participationUnavailable = "unavailable"
+
+// validatorRange is the validator indices held by a node. The ethereum-package hands out keys in participant order.
+type validatorRange struct {
+	start uint64
+	count int
+}
+
+func buildValidatorRanges(validatorKeys map[int]int) map[int]validatorRange {
+	if validatorKeys == nil {
+		return nil
+	}
+	var nodeIndices []int
+	for index := range validatorKeys {
+		nodeIndices = append(nodeIndices, index)
+	}
+	sort.Ints(nodeIndices)
+
+	ranges := make(map[int]validatorRange)
+	var next uint64
+	for _, index := range nodeIndices {
+		ranges[index] = validatorRange{start: next, count: validatorKeys[index]}
+		next += uint64(validatorKeys[index])
+	}
+	return ranges
+}
+
+func (r validatorRange) contains(index uint64) bool {
+	return index >= r.start && index < r.start+uint64(r.count)
+}
+
+func (r validatorRange) indices() []string {
+	indices := make([]string, r.count)
+	for i := range indices {
+		indices[i] = strconv.FormatUint(r.start+uint64(i), 10)
+	}
+	return indices
+}
+
+// getValidatorResult checks the proposer hit rate over the last slots, the attestation participation of the previous
+// epoch, and the effectiveness of the validators of each node under test. client must be following the canonical head.
+func (e *EthNetworkChecker) getValidatorResult(ctx context.Context, client *BeaconClientRpc, clients []*BeaconClientRpc, headSlot uint64) (*types.ValidatorResult, error) {
+	checks := e.validatorChecks
+	windowSlots := defaultProposalWindowSlots
+	if checks.ProposalWindowSlots > 0 {
+		windowSlots = checks.ProposalWindowSlots
+	}
+	slotsPerEpoch, err := e.getSlotsPerEpoch(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.ValidatorResult{
+		MinProposerHitRate:        checks.MinProposerHitRate,
+		MinParticipationRate:      checks.MinParticipationRate,
+		MinValidatorEffectiveness: checks.MinValidatorEffectiveness,
+	}
+
+	// genesis has no proposer, so the window starts at slot 1 at the earliest
+	firstSlot := uint64(1)
+	if headSlot >= uint64(windowSlots) {
+		firstSlot = headSlot - uint64(windowSlots) + 1
+	}
+	proposers, err := client.getProposers(ctx, firstSlot/slotsPerEpoch, headSlot/slotsPerEpoch)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	missedBy := make(map[uint64]int)
+	proposedBy := make(map[uint64]int)
+	for slot := firstSlot; slot <= headSlot; slot++ {
+		proposed, err := client.blockProposedAt(ctx, slot)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			break
+		}
+		result.SlotsChecked++
+		proposer, known := proposers[slot]
+		if proposed {
+			result.BlocksProposed++
+			if known {
+				proposedBy[proposer]++
+			}
+		} else if known {
+			missedBy[proposer]++
+		}
+	}
+	if result.SlotsChecked > 0 {
+		result.ProposerHitRate = float64(result.BlocksProposed) / float64(result.SlotsChecked)
+	}
+
+	currentEpoch := headSlot / slotsPerEpoch
+	if currentEpoch > 0 {
+		result.ParticipationEpoch = currentEpoch - 1
+		err = e.setParticipationRate(ctx, result, clients, currentEpoch)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		err = e.setNodeEffectiveness(ctx, result, client, proposedBy, missedBy)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	participationKnown := result.ParticipationSource != participationUnavailable
+	result.Passed = len(result.Errors) == 0 &&
+		result.ProposerHitRate >= checks.MinProposerHitRate &&
+		(!participationKnown || result.ParticipationRate >= checks.MinParticipationRate)
+	for index, node := range result.NodeEffectiveness {
+		if node.Effectiveness < checks.MinValidatorEffectiveness {
+			result.Passed = false
+			log.Warnf("---> Node %d validator effectiveness: %.2f", index, node.Effectiveness)
+		}
+	}
+	log.Infof("Proposer hit rate: %.2f over %d slots. Participation in epoch %d: %.2f (%s)", result.ProposerHitRate, result.SlotsChecked, result.ParticipationEpoch, result.ParticipationRate, result.ParticipationSource)
+	for _, msg := range result.Errors {
+		log.Warnf("Validator check error: %s", msg)
+	}
+	return result, nil
+}
+
+// setParticipationRate uses Lighthouse's validator_inclusion API when a Lighthouse node is available, since it
+// reports target-attesting balances. Otherwise, it falls back to the share of validators that were live.
+func (e *EthNetworkChecker) setParticipationRate(ctx context.Context, result *types.ValidatorResult, clients []*BeaconClientRpc, currentEpoch uint64) error {
+	for _, client := range clients {
+		if !client.endpoint.Pod.MatchesLabel(e.clientSchema.ClientNameLabel, "lighthouse") {
+			continue
+		}
+		rate, err := client.getLighthouseParticipation(ctx, currentEpoch)
+		if err != nil {
+			return err
+		}
+		result.ParticipationRate = rate
+		result.ParticipationSource = "lighthouse validator_inclusion"
+		return nil
+	}
+
+	if e.validatorRanges == nil {
+		result.ParticipationSource = participationUnavailable
+		return nil
+	}
+	var indices []string
+	for _, r := range e.validatorRanges {
+		indices = append(indices, r.indices()...)
+	}
+	live, err := clients[0].countLiveValidators(ctx, currentEpoch-1, indices)
+	if err != nil {
+		return err
+	}
+	if len(indices) > 0 {
+		result.ParticipationRate = float64(live) / float64(len(indices))
+	}
+	result.ParticipationSource = "validator liveness"
+	return nil
+}
+
+func (e *EthNetworkChecker) setNodeEffectiveness(ctx context.Context, result *types.ValidatorResult, client *BeaconClientRpc, proposedBy, missedBy map[uint64]int) error {
+	if e.validatorRanges == nil {
+		return nil
+	}
+	result.NodeEffectiveness = make(map[int]*types.ValidatorEffectiveness)
+	for _, pod := range e.podsUnderTest {
+		nodeIndex, ok := NodeIndexFromPodName(pod.Name)
+		if !ok {
+			continue
+		}
+		r, ok := e.validatorRanges[nodeIndex]
+		if !ok || r.count == 0 {
+			continue
+		}
+		if _, done := result.NodeEffectiveness[nodeIndex]; done {
+			continue
+		}
+
+		live, err := client.countLiveValidators(ctx, result.ParticipationEpoch, r.indices())
+		if err != nil {
+			return err
+		}
+		result.NodeEffectiveness[nodeIndex] = nodeEffectiveness(r, live, proposedBy, missedBy)
+	}
+	return nil
+}
+
+// nodeEffectiveness counts each live validator and each proposal made by the node as one performed duty.
+func nodeEffectiveness(r validatorRange, live int, proposedBy, missedBy map[uint64]int) *types.ValidatorEffectiveness {
+	node := &types.ValidatorEffectiveness{Validators: r.count, ValidatorsLive: live}
+	for proposer, count := range proposedBy {
+		if r.contains(proposer) {
+			node.ProposalsAssigned += count
+		}
+	}
+	for proposer, count := range missedBy {
+		if r.contains(proposer) {
+			node.ProposalsAssigned += count
+			node.ProposalsMissed += count
+		}
+	}
+	performed := node.ValidatorsLive + node.ProposalsAssigned - node.ProposalsMissed
+	node.Effectiveness = float64(performed) / float64(node.Validators+node.ProposalsAssigned)
+	return node
+}
+
+// getProposers maps the slots of the epochs to the validator index that was supposed to propose in them.
+func (c *BeaconClientRpc) getProposers(ctx context.Context, firstEpoch, lastEpoch uint64) (map[uint64]uint64, error) {
+	proposers := make(map[uint64]uint64)
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	for epoch := firstEpoch; epoch <= lastEpoch; epoch++ {
+		duties, err := c.client.ProposerDuties(ctx, &api.ProposerDutiesOpts{Epoch: phase0.Epoch(epoch)})
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "unable to fetch proposer duties for epoch %d from %s", epoch, c.endpoint.Pod.GetName())
+		}
+		for _, duty := range duties.Data {
+			proposers[uint64(duty.Slot)] = uint64(duty.ValidatorIndex)
+		}
+	}
+	return proposers, nil
+}
+
+func (c *BeaconClientRpc) blockProposedAt(ctx context.Context, slot uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+	defer cancel()
+	_, err := c.client.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{Block: strconv.FormatUint(slot, 10)})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 404 {
+			return false, nil
+		}
+		return false, stacktrace.Propagate(err, "unable to fetch block header for slot %d from %s", slot, c.endpoint.Pod.GetName())
+	}
+	return true, nil
+}
+
+type validatorInclusionResponse struct {
+	Data struct {
+		CurrentEpochActiveGwei           uint64 `json:"current_epoch_active_gwei"`
+		PreviousEpochTargetAttestingGwei uint64 `json:"previous_epoch_target_attesting_gwei"`
+	} `json:"data"`
+}
+
+// getLighthouseParticipation returns the share of the active balance that attested to the correct target in the
+// epoch before currentEpoch.
+func (c *BeaconClientRpc) getLighthouseParticipation(ctx context.Context, currentEpoch uint64) (float64, error) {
+	var body validatorInclusionResponse
+	err := c.callJson(ctx, nethttp.MethodGet, fmt.Sprintf("/lighthouse/validator_inclusion/%d/global", currentEpoch), nil, &body)
+	if err != nil {
+		return 0, err
+	}
+	if body.Data.CurrentEpochActiveGwei == 0 {
+		return 0, nil
+	}
+	return float64(body.Data.PreviousEpochTargetAttestingGwei) / float64(body.Data.CurrentEpochActiveGwei), nil
+}
+
+type livenessResponse struct {
+	Data []struct {
+		IsLive bool `json:"is_live"`
+	} `json:"data"`
+}
+
+// countLiveValidators returns how many of the validators attested or proposed in the epoch.
+func (c *BeaconClientRpc) countLiveValidators(ctx context.Context, epoch uint64, indices []string) (int, error) {
+	var body livenessResponse
+	err := c.callJson(ctx, nethttp.MethodPost, fmt.Sprintf("/eth/v1/validator/liveness/%d", epoch), indices, &body)
+	if err != nil {
+		return 0, err
+	}
+	live := 0
+	for _, validator := range body.Data {
+		if validator.IsLive {
+			live++
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,104 @@
+package ethereum
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"context"
+	"math"
+	"testing"
+)
+
+func TestBuildValidatorRanges(t *testing.T) {
+	if buildValidatorRanges(nil) != nil {
+		t.Fatal("expected unknown validator keys to produce no ranges")
+	}
+
+	// keys are handed out in participant order, regardless of map order
+	ranges := buildValidatorRanges(map[int]int{3: 16, 1: 8, 2: 0, 4: 4})
+	expected := map[int]validatorRange{
+		1: {start: 0, count: 8},
+		2: {start: 8, count: 0},
+		3: {start: 8, count: 16},
+		4: {start: 24, count: 4},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d", len(expected), len(ranges))
+	}
+	for index, r := range expected {
+		if ranges[index] != r {
+			t.Errorf("node %d: expected %+v, got %+v", index, r, ranges[index])
+		}
+	}
+
+	r := ranges[3]
+	if r.contains(7) || !r.contains(8) || !r.contains(23) || r.contains(24) {
+		t.Fatalf("unexpected bounds for %+v", r)
+	}
+	indices := ranges[4].indices()
+	if len(indices) != 4 || indices[0] != "24" || indices[3] != "27" {
+		t.Fatalf("unexpected indices %v", indices)
+	}
+	if len(ranges[2].indices()) != 0 {
+		t.Fatal("expected a node without keys to have no indices")
+	}
+}
+
+func TestNodeEffectiveness(t *testing.T) {
+	r := validatorRange{start: 8, count: 8}
+	type testCase struct {
+		name          string
+		live          int
+		proposedBy    map[uint64]int
+		missedBy      map[uint64]int
+		assigned      int
+		missed        int
+		effectiveness float64
+	}
+	testCases := []testCase{
+		{name: "every validator live, no proposals", live: 8, effectiveness: 1},
+		{name: "half live", live: 4, effectiveness: 0.5},
+		{
+			name: "proposals of other nodes are ignored",
+			live: 8, proposedBy: map[uint64]int{0: 3, 16: 1}, missedBy: map[uint64]int{7: 2},
+			effectiveness: 1,
+		},
+		{
+			// (6 live + 2 proposed) / (8 validators + 4 assigned)
+			name: "missed proposals count against the node",
+			live: 6, proposedBy: map[uint64]int{8: 1, 15: 1}, missedBy: map[uint64]int{9: 2, 20: 1},
+			assigned: 4, missed: 2, effectiveness: 8.0 / 12.0,
+		},
+		{
+			name: "offline node",
+			live: 0, missedBy: map[uint64]int{10: 1},
+			assigned: 1, missed: 1, effectiveness: 0,
+		},
+	}
+
+	for _, test := range testCases {
+		node := nodeEffectiveness(r, test.live, test.proposedBy, test.missedBy)
+		if node.Validators != 8 || node.ValidatorsLive != test.live || node.ProposalsAssigned != test.assigned || node.ProposalsMissed != test.missed {
+			t.Errorf("%s: unexpected duties %+v", test.name, node)
+		}
+		if math.Abs(node.Effectiveness-test.effectiveness) > 1e-9 {
+			t.Errorf("%s: expected effectiveness %.3f, got %.3f", test.name, test.effectiveness, node.Effectiveness)
+		}
+	}
+}
+
+func TestSetParticipationRateUnavailable(t *testing.T) {
+	checker := &EthNetworkChecker{clientSchema: confTypes.ClientSchema{ClientNameLabel: testClientNameLabel}}
+	clients := []*BeaconClientRpc{{endpoint: &kubernetes.PodEndpoint{
+		Pod: &kubernetes.Pod{Name: "cl-1-teku-geth", Labels: map[string]string{testClientNameLabel: "teku"}},
+	}}}
+
+	result := &types.ValidatorResult{}
+	err := checker.setParticipationRate(context.Background(), result, clients, 5)
+	if err != nil {
+		t.Fatalf("expected missing participation data not to be an error, got %s", err)
+	}
+	if result.ParticipationSource != participationUnavailable {
+		t.Fatalf("expected participation to be unavailable, got %s", result.ParticipationSource)
+	}
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sort"
+)
+
+// healthyPhases are the pod phases that don't indicate a problem.
+var healthyPhases = map[string]bool{
+	"Running":   true,
+	"Succeeded": true,
+}
+
+// startingReasons are the waiting reasons of containers that are still being started.
+var startingReasons = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// PodStatusSnapshot records the state of every pod in the enclave so it can be compared after a test.
+type PodStatusSnapshot map[string]*kubernetes.PodStatus
+
+func TakePodStatusSnapshot(ctx context.Context, kubeClient *kubernetes.KubeClient) (PodStatusSnapshot, error) {
+	statuses, err := kubeClient.PodStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(PodStatusSnapshot)
+	for _, status := range statuses {
+		snapshot[status.Name] = status
+	}
+	return snapshot, nil
+}
+
+// ComparePodStatus reports pods that restarted, disappeared or ended up in a bad state since the snapshot was taken.
+// Pods the faults were expected to kill are skipped, as are the pods that replaced them, matched by serviceIdLabel.
+func ComparePodStatus(
+	ctx context.Context,
+	kubeClient *kubernetes.KubeClient,
+	before PodStatusSnapshot,
+	podsUnderTest []*chaos_mesh.PodUnderTest,
+	serviceIdLabel string,
+) (*types.PodStatusResult, error) {
+	after, err := TakePodStatusSnapshot(ctx, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	result := comparePodSnapshots(before, after, podsUnderTest, serviceIdLabel)
+	for _, issue := range result.UnexpectedIssues {
+		container := ""
+		if issue.Container != "" {
+			container = fmt.Sprintf(" container %s", issue.Container)
+		}
+		log.Warnf("Unexpected pod issue: %s%s phase: %s restarts: %d waiting: %s terminated: %s bystander: %t", issue.Pod, container, issue.Phase, issue.Restarts, issue.Waiting, issue.Reason, issue.Bystander)
+	}
+	for _, pod := range result.PendingPods {
+		log.Infof("Pod %s was created during the test and is still pending", pod)
+	}
+	return result, nil
+}
+
+func comparePodSnapshots(before, after PodStatusSnapshot, podsUnderTest []*chaos_mesh.PodUnderTest, serviceIdLabel string) *types.PodStatusResult {
+	targeted := make(map[string]bool)
+	expectedDead := make(map[string]bool)
+	expectedDeadServices := make(map[string]bool)
+	for _, pod := range podsUnderTest {
+		targeted[pod.Name] = true
+		if pod.ExpectDeath {
+			expectedDead[pod.Name] = true
+			if serviceId, exists := pod.Labels[serviceIdLabel]; exists {
+				expectedDeadServices[serviceId] = true
+			}
+		}
+	}
+	isExpectedDead := func(status *kubernetes.PodStatus) bool {
+		return expectedDead[status.Name] || expectedDeadServices[status.Labels[serviceIdLabel]]
+	}
+
+	result := &types.PodStatusResult{}
+	for _, name := range sortedPodNames(before) {
+		if _, exists := after[name]; !exists && !isExpectedDead(before[name]) {
+			result.UnexpectedIssues = append(result.UnexpectedIssues, &types.PodStatusIssue{
+				Pod:       name,
+				Phase:     "Deleted",
+				Bystander: !targeted[name],
+			})
+		}
+	}
+	for _, name := range sortedPodNames(after) {
+		status := after[name]
+		if isExpectedDead(status) {
+			continue
+		}
+		issues := podStatusIssues(before[name], status, !targeted[name])
+		if len(issues) == 0 && before[name] == nil && status.Phase == "Pending" {
+			result.PendingPods = append(result.PendingPods, name)
+		}
+		result.UnexpectedIssues = append(result.UnexpectedIssues, issues...)
+	}
+	return result
+}
+
+// podStatusIssues compares a pod against its earlier status, which is nil if the pod didn't exist yet. Problems that
+// were already present before the test aren't reported again, and new pods that are still starting aren't problems.
+func podStatusIssues(before, after *kubernetes.PodStatus, bystander bool) []*types.PodStatusIssue {
+	var issues []*types.PodStatusIssue
+	containerNames := make([]string, 0, len(after.Containers))
+	for name := range after.Containers {
+		containerNames = append(containerNames, name)
+	}
+	sort.Strings(containerNames)
+
+	for _, name := range containerNames {
+		container := after.Containers[name]
+		var previous *kubernetes.ContainerStatus
+		if before != nil {
+			previous = before.Containers[name]
+		}
+		restarts := container.RestartCount
+		wasWaiting := false
+		if previous != nil {
+			restarts -= previous.RestartCount
+			wasWaiting = previous.WaitingReason != ""
+		}
+		starting := before == nil && startingReasons[container.WaitingReason]
+		waitingSinceTest := container.WaitingReason != "" && !wasWaiting && !starting
+		if restarts <= 0 && !waitingSinceTest {
+			continue
+		}
+
+		issue := &types.PodStatusIssue{
+			Pod:       after.Name,
+			Container: name,
+			Phase:     after.Phase,
+			Restarts:  max(restarts, 0),
+			Waiting:   container.WaitingReason,
+			Bystander: bystander,
+		}
+		// the last termination predates the test unless the container restarted during it
+		if restarts > 0 && container.TerminationReason != "" {
+			exitCode := container.ExitCode
+			issue.Reason = container.TerminationReason
+			issue.ExitCode = &exitCode
+		}
+		issues = append(issues, issue)
+	}
+
+	newAndPending := before == nil && after.Phase == "Pending"
+	if len(issues) == 0 && !healthyPhases[after.Phase] && !newAndPending && (before == nil || healthyPhases[before.Phase]) {
+		issues = append(issues, &types.PodStatusIssue{
+			Pod:       after.Name,
+			Phase:     after.Phase,
+			Bystander: bystander,
+		})
+	}
+	return issues
+}
+
+func sortedPodNames(snapshot PodStatusSnapshot) []string {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
+	"attacknet/cmd/pkg/kubernetes"
+	"testing"
+)
+
+func runningPod(name string, containers map[string]*kubernetes.ContainerStatus) *kubernetes.PodStatus {
+	return &kubernetes.PodStatus{Name: name, Phase: "Running", Containers: containers}
+}
+
+func TestPodStatusIssues(t *testing.T) {
+	type testCase struct {
+		name      string
+		before    *kubernetes.PodStatus
+		after     *kubernetes.PodStatus
+		bystander bool
+		// expected issues, keyed by container. "" is an issue with the pod itself
+		expected map[string]int
+	}
+	testCases := []testCase{
+		{
+			name:   "healthy pod",
+			before: runningPod("el-1", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 1}}),
+			after:  runningPod("el-1", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 1}}),
+		},
+		{
+			name:      "restarted bystander",
+			before:    runningPod("el-2", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 1}}),
+			after:     runningPod("el-2", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 3, TerminationReason: "OOMKilled", ExitCode: 137}}),
+			bystander: true,
+			expected:  map[string]int{"geth": 2},
+		},
+		{
+			name:     "crash looping container",
+			before:   runningPod("cl-1", map[string]*kubernetes.ContainerStatus{"lighthouse": {}, "sidecar": {}}),
+			after:    runningPod("cl-1", map[string]*kubernetes.ContainerStatus{"lighthouse": {RestartCount: 4, WaitingReason: "CrashLoopBackOff", TerminationReason: "Error", ExitCode: 1}, "sidecar": {}}),
+			expected: map[string]int{"lighthouse": 4},
+		},
+		{
+			name:   "already crash looping before the test",
+			before: runningPod("cl-2", map[string]*kubernetes.ContainerStatus{"lighthouse": {RestartCount: 4, WaitingReason: "CrashLoopBackOff"}}),
+			after:  runningPod("cl-2", map[string]*kubernetes.ContainerStatus{"lighthouse": {RestartCount: 4, WaitingReason: "CrashLoopBackOff"}}),
+		},
+		{
+			name:     "new pod stuck waiting",
+			after:    &kubernetes.PodStatus{Name: "cl-3", Phase: "Pending", Containers: map[string]*kubernetes.ContainerStatus{"lighthouse": {WaitingReason: "ImagePullBackOff"}}},
+			expected: map[string]int{"lighthouse": 0},
+		},
+		{
+			name:      "new pod still starting",
+			after:     &kubernetes.PodStatus{Name: "cl-4", Phase: "Pending", Containers: map[string]*kubernetes.ContainerStatus{"lighthouse": {WaitingReason: "ContainerCreating"}}},
+			bystander: true,
+		},
+		{
+			name:      "new pod not scheduled yet",
+			after:     &kubernetes.PodStatus{Name: "cl-5", Phase: "Pending", Containers: map[string]*kubernetes.ContainerStatus{}},
+			bystander: true,
+		},
+		{
+			// the last termination happened before the test, only the waiting state is new
+			name:     "waiting after an old termination",
+			before:   runningPod("el-5", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 2, TerminationReason: "OOMKilled", ExitCode: 137}}),
+			after:    runningPod("el-5", map[string]*kubernetes.ContainerStatus{"geth": {RestartCount: 2, WaitingReason: "ImagePullBackOff", TerminationReason: "OOMKilled", ExitCode: 137}}),
+			expected: map[string]int{"geth": 0},
+		},
+		{
+			name:     "pod failed",
+			before:   runningPod("el-3", map[string]*kubernetes.ContainerStatus{}),
+			after:    &kubernetes.PodStatus{Name: "el-3", Phase: "Failed", Containers: map[string]*kubernetes.ContainerStatus{}},
+			expected: map[string]int{"": 0},
+		},
+		{
+			name:   "pod already failed",
+			before: &kubernetes.PodStatus{Name: "el-4", Phase: "Failed", Containers: map[string]*kubernetes.ContainerStatus{}},
+			after:  &kubernetes.PodStatus{Name: "el-4", Phase: "Failed", Containers: map[string]*kubernetes.ContainerStatus{}},
+		},
+	}
+
+	for _, test := range testCases {
+		issues := podStatusIssues(test.before, test.after, test.bystander)
+		if len(issues) != len(test.expected) {
+			t.Errorf("%s: expected %d issues, got %d", test.name, len(test.expected), len(issues))
+			continue
+		}
+		for _, issue := range issues {
+			restarts, expected := test.expected[issue.Container]
+			if !expected || issue.Restarts != restarts {
+				t.Errorf("%s: unexpected issue %+v", test.name, issue)
+			}
+			if issue.Pod != test.after.Name || issue.Bystander != test.bystander {
+				t.Errorf("%s: issue reported for the wrong pod %+v", test.name, issue)
+			}
+			if issue.Reason != "" && issue.ExitCode == nil {
+				t.Errorf("%s: expected the exit code of a terminated container, got %+v", test.name, issue)
+			}
+			if issue.Restarts == 0 && (issue.Reason != "" || issue.ExitCode != nil) {
+				t.Errorf("%s: expected no termination info without restarts during the test, got %+v", test.name, issue)
+			}
+		}
+	}
+}
+
+func TestComparePodSnapshots(t *testing.T) {
+	const serviceIdLabel = "service-id"
+	restarted := map[string]*kubernetes.ContainerStatus{"main": {RestartCount: 1}}
+	healthy := map[string]*kubernetes.ContainerStatus{"main": {}}
+	labelled := func(status *kubernetes.PodStatus, serviceId string) *kubernetes.PodStatus {
+		status.Labels = map[string]string{serviceIdLabel: serviceId}
+		return status
+	}
+
+	before := PodStatusSnapshot{
+		"el-1-abc":  labelled(runningPod("el-1-abc", healthy), "el-1"),
+		"cl-1-abc":  labelled(runningPod("cl-1-abc", healthy), "cl-1"),
+		"el-2-abc":  labelled(runningPod("el-2-abc", healthy), "el-2"),
+		"el-3-abc":  labelled(runningPod("el-3-abc", healthy), "el-3"),
+		"grafana-0": runningPod("grafana-0", healthy),
+	}
+	after := PodStatusSnapshot{
+		// killed by the fault and replaced under a new name
+		"el-1-def": labelled(runningPod("el-1-def", restarted), "el-1"),
+		// targeted but not expected to die
+		"cl-1-abc": labelled(runningPod("cl-1-abc", restarted), "cl-1"),
+		// bystander that restarted
+		"el-2-abc":  labelled(runningPod("el-2-abc", restarted), "el-2"),
+		"grafana-0": runningPod("grafana-0", healthy),
+		// el-3-abc is a bystander that disappeared
+		// created during the test and still being scheduled
+		"vc-1-def": {Name: "vc-1-def", Phase: "Pending", Containers: map[string]*kubernetes.ContainerStatus{}},
+	}
+	podsUnderTest := []*chaos_mesh.PodUnderTest{
+		{Name: "el-1-abc", Labels: map[string]string{serviceIdLabel: "el-1"}, ExpectDeath: true},
+		{Name: "cl-1-abc", Labels: map[string]string{serviceIdLabel: "cl-1"}},
+	}
+
+	result := comparePodSnapshots(before, after, podsUnderTest, serviceIdLabel)
+	expected := map[string]struct {
+		phase     string
+		bystander bool
+	}{
+		"cl-1-abc": {"Running", false},
+		"el-2-abc": {"Running", true},
+		"el-3-abc": {"Deleted", true},
+	}
+	if len(result.UnexpectedIssues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d", len(expected), len(result.UnexpectedIssues))
+	}
+	for _, issue := range result.UnexpectedIssues {
+		e, ok := expected[issue.Pod]
+		if !ok || issue.Phase != e.phase || issue.Bystander != e.bystander {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+	if len(result.PendingPods) != 1 || result.PendingPods[0] != "vc-1-def" {
+		t.Errorf("expected vc-1-def to be reported as pending, got %v", result.PendingPods)
+	}
+}
@@ -0,0 +1,260 @@
+package health
+
+import (
+	"attacknet/cmd/pkg/health/types"
+	"attacknet/cmd/pkg/kubernetes"
+	confTypes "attacknet/cmd/pkg/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPrometheusTimeout = 10 * time.Second
+
+// PrometheusClient runs PromQL health checks against the enclave's prometheus. It connects on the first query, so
+// suites without prometheus checks never open a connection.
+type PrometheusClient struct {
+	kubeClient *kubernetes.KubeClient
+	podName    string
+	port       int
+	accessMode confTypes.AccessMode
+	timeout    time.Duration
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	endpoint *kubernetes.PodEndpoint
+}
+
+type promSample struct {
+	labels map[string]string
+	value  float64
+}
+
+func CreatePrometheusClient(kubeClient *kubernetes.KubeClient, config confTypes.AttacknetConfig) (*PrometheusClient, error) {
+	port, err := strconv.Atoi(config.PrometheusPodPort)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to decode port number %s", config.PrometheusPodPort)
+	}
+	timeout := time.Duration(config.RpcTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultPrometheusTimeout
+	}
+	return &PrometheusClient{
+		kubeClient: kubeClient,
+		podName:    config.PrometheusPodName,
+		port:       port,
+		accessMode: config.AccessMode,
+		timeout:    timeout,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// RunChecks runs every check. Checks whose query fails are reported as failing rather than aborting the round.
+func (p *PrometheusClient) RunChecks(ctx context.Context, checks []confTypes.PrometheusCheck) []*types.PrometheusCheckResult {
+	results := make([]*types.PrometheusCheckResult, len(checks))
+	for i, check := range checks {
+		samples, err := p.query(ctx, check.Query)
+		if err != nil {
+			log.Warnf("Prometheus check %s failed to query: %s", check.Name, err)
+			results[i] = &types.PrometheusCheckResult{
+				Name:      check.Name,
+				Query:     check.Query,
+				Condition: checkCondition(check),
+				Error:     err.Error(),
+			}
+			continue
+		}
+		results[i] = evaluatePrometheusCheck(check, samples)
+		if !results[i].Passed {
+			log.Warnf("Prometheus check %s failed. %d of %d series don't satisfy '%s'", check.Name, len(results[i].FailingSeries), results[i].SeriesChecked, results[i].Condition)
+		}
+	}
+	return results
+}
+
+func (p *PrometheusClient) connect(ctx context.Context) (*kubernetes.PodEndpoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoint != nil && p.endpoint.Alive() {
+		return p.endpoint, nil
+	}
+	pod, err := p.kubeClient.PodByName(ctx, p.podName)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to locate prometheus pod %s", p.podName)
+	}
+	endpoint, err := p.kubeClient.OpenEndpoint(pod, p.podName, p.port, p.accessMode)
+	if err != nil {
+		return nil, err
+	}
+	p.endpoint = endpoint
+	return endpoint, nil
+}
+
+// disconnect drops the endpoint so the next query reconnects, e.g. after prometheus was restarted.
+func (p *PrometheusClient) disconnect() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoint != nil {
+		p.endpoint.Close()
+		p.endpoint = nil
+	}
+}
+
+func (p *PrometheusClient) Close() {
+	p.disconnect()
+}
+
+func (p *PrometheusClient) query(ctx context.Context, query string) ([]*promSample, error) {
+	endpoint, err := p.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	queryUrl := fmt.Sprintf("http://%s/api/v1/query?%s", endpoint.Address, url.Values{"query": {query}}.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryUrl, nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to build prometheus request")
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		p.disconnect()
+		return nil, stacktrace.Propagate(err, "request to prometheus failed")
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status    string `json:"status"`
+		Error     string `json:"error"`
+		ErrorType string `json:"errorType"`
+		Data      struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to decode prometheus response (status %d)", resp.StatusCode)
+	}
+	if body.Status != "success" {
+		return nil, stacktrace.NewError("prometheus rejected query '%s': %s: %s", query, body.ErrorType, body.Error)
+	}
+	return parsePromResult(body.Data.ResultType, body.Data.Result)
+}
+
+// parsePromResult decodes instant vector and scalar results. Values are encoded as [timestamp, "value"].
+func parsePromResult(resultType string, result json.RawMessage) ([]*promSample, error) {
+	switch resultType {
+	case "vector":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		}
+		err := json.Unmarshal(result, &series)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "unable to decode prometheus vector")
+		}
+		samples := make([]*promSample, len(series))
+		for i, s := range series {
+			value, err := parsePromValue(s.Value)
+			if err != nil {
+				return nil, err
+			}
+			samples[i] = &promSample{labels: s.Metric, value: value}
+		}
+		return samples, nil
+	case "scalar":
+		var pair [2]interface{}
+		err := json.Unmarshal(result, &pair)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "unable to decode prometheus scalar")
+		}
+		value, err := parsePromValue(pair)
+		if err != nil {
+			return nil, err
+		}
+		return []*promSample{{value: value}}, nil
+	default:
+		return nil, stacktrace.NewError("unsupported prometheus result type %s. queries must return an instant vector or scalar", resultType)
+	}
+}
+
+func parsePromValue(pair [2]interface{}) (float64, error) {
+	raw, ok := pair[1].(string)
+	if !ok {
+		return 0, stacktrace.NewError("unexpected prometheus value %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "unable to parse prometheus value %s", raw)
+	}
+	return value, nil
+}
+
+func evaluatePrometheusCheck(check confTypes.PrometheusCheck, samples []*promSample) *types.PrometheusCheckResult {
+	result := &types.PrometheusCheckResult{
+		Name:          check.Name,
+		Query:         check.Query,
+		Condition:     checkCondition(check),
+		SeriesChecked: len(samples),
+		FailingSeries: make(map[string]float64),
+	}
+	for _, sample := range samples {
+		if !satisfiesCheck(check, sample.value) {
+			result.FailingSeries[seriesKey(sample.labels)] = sample.value
+		}
+	}
+	// no series usually means the metric doesn't exist, so there's nothing to vouch for the network's health
+	result.Passed = len(samples) > 0 && len(result.FailingSeries) == 0
+	return result
+}
+
+func satisfiesCheck(check confTypes.PrometheusCheck, value float64) bool {
+	if check.Operator == "" {
+		return value != 0
+	}
+	threshold := *check.Threshold
+	switch check.Operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+func checkCondition(check confTypes.PrometheusCheck) string {
+	if check.Operator == "" {
+		return "!= 0"
+	}
+	return fmt.Sprintf("%s %s", check.Operator, strconv.FormatFloat(*check.Threshold, 'f', -1, 64))
+}
+
+// seriesKey formats labels the way prometheus does, e.g. {instance="cl-1", job="beacon"}.
+func seriesKey(labels map[string]string) string {
+	var pairs []string
+	for k, v := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
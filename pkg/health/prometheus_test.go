@@ -0,0 +1,58 @@
+package health
+
+import (
+	confTypes "attacknet/cmd/pkg/types"
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePromResult(t *testing.T) {
+	vector := json.RawMessage(`[{"metric":{"job":"cl-1"},"value":[1700000000.1,"12"]},{"metric":{"job":"cl-2"},"value":[1700000000.1,"0"]}]`)
+	samples, err := parsePromResult("vector", vector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].labels["job"] != "cl-1" || samples[0].value != 12 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+
+	samples, err = parsePromResult("scalar", json.RawMessage(`[1700000000.1,"3.5"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].value != 3.5 {
+		t.Fatalf("unexpected scalar %+v", samples)
+	}
+
+	_, err = parsePromResult("matrix", json.RawMessage(`[]`))
+	if err == nil {
+		t.Fatal("expected range vectors to be rejected")
+	}
+}
+
+func TestEvaluatePrometheusCheck(t *testing.T) {
+	samples := []*promSample{
+		{labels: map[string]string{"job": "cl-1", "instance": "a"}, value: 12},
+		{labels: map[string]string{"job": "cl-2"}, value: 0},
+	}
+
+	threshold := 1.0
+	result := evaluatePrometheusCheck(confTypes.PrometheusCheck{Name: "peers", Query: "libp2p_peers", Operator: ">=", Threshold: &threshold}, samples)
+	if result.Passed || result.Condition != ">= 1" || result.SeriesChecked != 2 {
+		t.Fatalf("expected the check to fail, got %+v", result)
+	}
+	if value, failing := result.FailingSeries[`{job="cl-2"}`]; !failing || value != 0 || len(result.FailingSeries) != 1 {
+		t.Fatalf("expected only cl-2 to fail, got %v", result.FailingSeries)
+	}
+
+	// without an operator, every value has to be non-zero
+	result = evaluatePrometheusCheck(confTypes.PrometheusCheck{Name: "head", Query: "beacon_head_slot > bool 0"}, samples[:1])
+	if !result.Passed {
+		t.Fatalf("expected the boolean check to pass, got %+v", result)
+	}
+
+	result = evaluatePrometheusCheck(confTypes.PrometheusCheck{Name: "missing", Query: "does_not_exist"}, nil)
+	if result.Passed {
+		t.Fatal("expected a query without series to fail")
+	}
+}
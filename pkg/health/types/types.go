@@ -11,6 +11,47 @@ type BlockConsensusTestResult struct {
 	ConsensusHash               string            `yaml:"consensus_hash"`
 	FailingClientsReportedBlock map[string]uint64 `yaml:"failing_clients_reported_block"`
 	FailingClientsReportedHash  map[string]string `yaml:"failing_clients_reported_hash"`
+	// why each failing client is off the consensus head. only set for the latest block
+	ForkStatus map[string]*NodeForkStatus `yaml:"fork_status,omitempty"`
+	// set when no block is reported by a strict majority of clients. the consensus block is then the one reported by
+	// the largest partition, and the check fails
+	NoMajority bool                  `yaml:"no_majority"`
+	Partitions []*ConsensusPartition `yaml:"partitions,omitempty"` // only listed when there's no majority
+}
+
+// ConsensusPartition is a group of clients that report the same block.
+type ConsensusPartition struct {
+	BlockNumber uint64   `yaml:"block_number"`
+	BlockHash   string   `yaml:"block_hash"`
+	Clients     []string `yaml:"clients"`
+}
+
+type ForkStatus string
+
+const (
+	ForkStatusLagging     ForkStatus = "lagging"     // the node's head is an ancestor of the consensus head
+	ForkStatusAhead       ForkStatus = "ahead"       // the consensus head is an ancestor of the node's head
+	ForkStatusForked      ForkStatus = "forked"      // the node's head is on a different branch
+	ForkStatusUnreachable ForkStatus = "unreachable" // the node's chain couldn't be queried
+	ForkStatusUnknown     ForkStatus = "unknown"     // the node's head was queried, but not how it relates to the consensus head
+)
+
+type NodeForkStatus struct {
+	Status ForkStatus `yaml:"status"`
+	// blocks between the node's head and the consensus head for lagging and ahead nodes. blocks between the node's
+	// head and the common ancestor for forked nodes
+	Depth          uint64 `yaml:"depth"`
+	CommonAncestor uint64 `yaml:"common_ancestor"`
+	Error          string `yaml:"error,omitempty"`
+}
+
+// Reorg is a client switching to a head that doesn't descend from its previous head.
+type Reorg struct {
+	Pod     string `yaml:"pod"`
+	OldHead string `yaml:"old_head"`
+	NewHead string `yaml:"new_head"`
+	Depth   uint64 `yaml:"depth"` // blocks of the old head's branch that were dropped
+	Time    int64  `yaml:"time"`
 }
 
 type BlockConsensusArtifact struct {
@@ -21,8 +62,102 @@ type BlockConsensusArtifact struct {
 }
 
 type HealthCheckResult struct {
-	LatestElBlockResult    *BlockConsensusArtifact `yaml:"latest_el_block_health_result"`
-	FinalizedElBlockResult *BlockConsensusArtifact `yaml:"finalized_el_block_health_result"`
-	LatestClBlockResult    *BlockConsensusArtifact `yaml:"latest_cl_block_health_result"`
-	FinalizedClBlockResult *BlockConsensusArtifact `yaml:"finalized_cl_block_health_result"`
+	LatestElBlockResult    *BlockConsensusArtifact  `yaml:"latest_el_block_health_result"`
+	FinalizedElBlockResult *BlockConsensusArtifact  `yaml:"finalized_el_block_health_result"`
+	LatestClBlockResult    *BlockConsensusArtifact  `yaml:"latest_cl_block_health_result"`
+	FinalizedClBlockResult *BlockConsensusArtifact  `yaml:"finalized_cl_block_health_result"`
+	ElNodeStatusResult     *NodeStatusResult        `yaml:"el_node_status_result,omitempty"`
+	ClNodeStatusResult     *NodeStatusResult        `yaml:"cl_node_status_result,omitempty"`
+	FinalityResult         *FinalityResult          `yaml:"finality_result,omitempty"`
+	ValidatorResult        *ValidatorResult         `yaml:"validator_result,omitempty"`
+	Reorgs                 []*Reorg                 `yaml:"reorgs,omitempty"`
+	PodStatusResult        *PodStatusResult         `yaml:"pod_status_result,omitempty"`
+	PrometheusResults      []*PrometheusCheckResult `yaml:"prometheus_results,omitempty"`
+}
+
+// PrometheusCheckResult is the outcome of a PromQL health check. Series are keyed by their labels.
+type PrometheusCheckResult struct {
+	Name          string             `yaml:"name"`
+	Query         string             `yaml:"query"`
+	Condition     string             `yaml:"condition"` // what every value had to satisfy, e.g. "> 3"
+	Passed        bool               `yaml:"passed"`
+	SeriesChecked int                `yaml:"series_checked"`
+	FailingSeries map[string]float64 `yaml:"failing_series,omitempty"`
+	Error         string             `yaml:"error,omitempty"` // set when prometheus couldn't be queried
+}
+
+type FinalityCheckpoints struct {
+	JustifiedEpoch uint64 `yaml:"justified_epoch"`
+	JustifiedRoot  string `yaml:"justified_root"`
+	FinalizedEpoch uint64 `yaml:"finalized_epoch"`
+	FinalizedRoot  string `yaml:"finalized_root"`
+	Error          string `yaml:"error,omitempty"` // set when the client couldn't be queried
+}
+
+// FinalityResult compares the finality checkpoints of the beacon nodes and how far finality trails the head.
+type FinalityResult struct {
+	ConsensusCheckpoints              *FinalityCheckpoints            `yaml:"consensus_checkpoints"`
+	FailingClientsReportedCheckpoints map[string]*FinalityCheckpoints `yaml:"failing_clients_reported_checkpoints"`
+	HeadEpoch                         uint64                          `yaml:"head_epoch"`
+	FinalityLagEpochs                 uint64                          `yaml:"finality_lag_epochs"`
+	MaxFinalityLagEpochs              *int                            `yaml:"max_finality_lag_epochs,omitempty"`
+	FinalityLagExceeded               bool                            `yaml:"finality_lag_exceeded"`
+}
+
+type NodeStatus struct {
+	PeerCount    uint64 `yaml:"peer_count"`
+	Syncing      bool   `yaml:"syncing"`
+	SyncDistance uint64 `yaml:"sync_distance,omitempty"`
+	Error        string `yaml:"error,omitempty"` // set when the client couldn't be queried
+}
+
+// NodeStatusResult lists the clients that have too few peers or are still syncing.
+type NodeStatusResult struct {
+	MinPeers       int                    `yaml:"min_peers"`
+	AllowSyncing   bool                   `yaml:"allow_syncing"`
+	FailingClients map[string]*NodeStatus `yaml:"failing_clients"`
+}
+
+// PodStatusIssue is a pod that restarted, disappeared or ended up in a bad state during a test.
+type PodStatusIssue struct {
+	Pod       string `yaml:"pod"`
+	Container string `yaml:"container,omitempty"`
+	Phase     string `yaml:"phase"`
+	Restarts  int    `yaml:"restarts_during_test"`
+	Waiting   string `yaml:"waiting_reason,omitempty"`     // why a container isn't running, e.g. CrashLoopBackOff
+	Reason    string `yaml:"termination_reason,omitempty"` // why a container last terminated, e.g. OOMKilled
+	ExitCode  *int   `yaml:"exit_code,omitempty"`
+	Bystander bool   `yaml:"bystander"` // whether the pod was untouched by the faults
+}
+
+type PodStatusResult struct {
+	// issues of pods that weren't expected to die. issues of bystander pods fail the test
+	UnexpectedIssues []*PodStatusIssue `yaml:"unexpected_issues"`
+	// pods created during the test that are still being scheduled or started. these don't fail the test
+	PendingPods []string `yaml:"pending_pods,omitempty"`
+}
+
+// ValidatorEffectiveness is the share of a node's duties its validators performed. Each validator that was live in
+// the previous epoch and each block proposed in the window counts as a performed duty.
+type ValidatorEffectiveness struct {
+	Validators        int     `yaml:"validators"`
+	ValidatorsLive    int     `yaml:"validators_live"`
+	ProposalsAssigned int     `yaml:"proposals_assigned"`
+	ProposalsMissed   int     `yaml:"proposals_missed"`
+	Effectiveness     float64 `yaml:"effectiveness"`
+}
+
+type ValidatorResult struct {
+	SlotsChecked              int                             `yaml:"slots_checked"`
+	BlocksProposed            int                             `yaml:"blocks_proposed"`
+	ProposerHitRate           float64                         `yaml:"proposer_hit_rate"`
+	MinProposerHitRate        float64                         `yaml:"min_proposer_hit_rate"`
+	ParticipationEpoch        uint64                          `yaml:"participation_epoch"`
+	ParticipationRate         float64                         `yaml:"participation_rate"`
+	ParticipationSource       string                          `yaml:"participation_source"` // lighthouse validator_inclusion, validator liveness or unavailable
+	MinParticipationRate      float64                         `yaml:"min_participation_rate"`
+	NodeEffectiveness         map[int]*ValidatorEffectiveness `yaml:"node_effectiveness,omitempty"` // nodes under test, keyed by node index
+	MinValidatorEffectiveness float64                         `yaml:"min_validator_effectiveness"`
+	Errors                    []string                        `yaml:"errors,omitempty"`
+	Passed                    bool                            `yaml:"passed"`
 }
@@ -0,0 +1,77 @@
+package kubernetes
+
+import (
+	"attacknet/cmd/pkg/types"
+	"fmt"
+	"github.com/kurtosis-tech/stacktrace"
+	"net"
+	"strconv"
+)
+
+// PodEndpoint is the address a port of a pod can be reached at. Endpoints opened through a port-forward own the
+// port-forward and stop it when they're closed.
+type PodEndpoint struct {
+	Pod     KubePod
+	Address string // host:port
+	session *PortForwardsSession
+}
+
+// OpenEndpoint opens an endpoint to the target port of the pod using the access mode. serviceName is only used in
+// service-dns mode.
+func (c *KubeClient) OpenEndpoint(pod KubePod, serviceName string, targetPort int, mode types.AccessMode) (*PodEndpoint, error) {
+	switch mode {
+	case types.PodIp:
+		return c.PodIpEndpoint(pod, targetPort)
+	case types.ServiceDns:
+		return c.ServiceDnsEndpoint(pod, serviceName, targetPort), nil
+	default:
+		return c.PortForwardEndpoint(pod, targetPort)
+	}
+}
+
+// PortForwardEndpoint forwards an ephemeral local port to the target port of the pod.
+func (c *KubeClient) PortForwardEndpoint(pod KubePod, targetPort int) (*PodEndpoint, error) {
+	session, err := c.StartPortForwardSession(pod, targetPort)
+	if err != nil {
+		return nil, err
+	}
+	return &PodEndpoint{
+		Pod:     pod,
+		Address: fmt.Sprintf("localhost:%d", session.LocalPort),
+		session: session,
+	}, nil
+}
+
+// PodIpEndpoint points at the target port on the IP of the pod. Only reachable from inside the cluster.
+func (c *KubeClient) PodIpEndpoint(pod KubePod, targetPort int) (*PodEndpoint, error) {
+	livePod, ok := pod.(*Pod)
+	if !ok || livePod.IP == "" {
+		return nil, stacktrace.NewError("pod %s has no IP assigned", pod.GetName())
+	}
+	return &PodEndpoint{
+		Pod:     pod,
+		Address: net.JoinHostPort(livePod.IP, strconv.Itoa(targetPort)),
+	}, nil
+}
+
+// ServiceDnsEndpoint points at the target port of the service in the client's namespace. Only reachable from inside
+// the cluster.
+func (c *KubeClient) ServiceDnsEndpoint(pod KubePod, serviceName string, targetPort int) *PodEndpoint {
+	host := fmt.Sprintf("%s.%s.svc", serviceName, c.namespace)
+	return &PodEndpoint{
+		Pod:     pod,
+		Address: net.JoinHostPort(host, strconv.Itoa(targetPort)),
+	}
+}
+
+// Alive returns whether the endpoint can still be used. Endpoints that don't go through a port-forward stay alive.
+func (e *PodEndpoint) Alive() bool {
+	return e.session == nil || e.session.Alive()
+}
+
+// Close stops the port-forward of the endpoint, if any. It's safe to call more than once.
+func (e *PodEndpoint) Close() {
+	if e.session != nil {
+		e.session.Close()
+	}
+}
@@ -2,10 +2,12 @@ package kubernetes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
 	//api "github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
-	//corev1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -14,6 +16,7 @@ import (
 	"os"
 	"path/filepath"
 	pkgclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
 )
 
 type KubeClient struct {
@@ -22,12 +25,12 @@ type KubeClient struct {
 	namespace      string
 }
 
+// CreateKubeClient uses the service account of the pod when attacknet runs inside the cluster, and the default
+// kubeconfig file otherwise.
 func CreateKubeClient(namespace string) (*KubeClient, error) {
-	kubeConfigPath := filepath.Join(os.Getenv("HOME"), ".kube", "config")
-
-	kubeConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	kubeConfig, err := loadKubeConfig()
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "Unable to load the default kubeconfig file")
+		return nil, err
 	}
 
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
@@ -48,6 +51,24 @@ func CreateKubeClient(namespace string) (*KubeClient, error) {
 	return c, nil
 }
 
+func loadKubeConfig() (*rest.Config, error) {
+	kubeConfig, err := rest.InClusterConfig()
+	if err == nil {
+		log.Info("Running inside the cluster, using the in-cluster kubernetes config")
+		return kubeConfig, nil
+	}
+	if !errors.Is(err, rest.ErrNotInCluster) {
+		return nil, stacktrace.Propagate(err, "Unable to load the in-cluster kubernetes config")
+	}
+
+	kubeConfigPath := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	kubeConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "Unable to load the default kubeconfig file")
+	}
+	return kubeConfig, nil
+}
+
 func (c *KubeClient) CreateDerivedClientWithSchema(scheme *runtime.Scheme) (pkgclient.Client, error) {
 	return pkgclient.New(c.clientInternal, pkgclient.Options{Scheme: scheme})
 }
@@ -70,10 +91,97 @@ func (c *KubeClient) PodsMatchingLabel(ctx context.Context, labelKey, labelValue
 	}
 
 	var matchingPods []KubePod
-	for _, pod := range pods.Items {
-		labels := pod.GetLabels()
-		matchingPods = append(matchingPods, &Pod{Name: pod.Name, Labels: labels})
+	for i := range pods.Items {
+		matchingPods = append(matchingPods, toPod(&pods.Items[i]))
 	}
 
 	return matchingPods, nil
 }
+
+// PodByName returns the pod with the given name.
+func (c *KubeClient) PodByName(ctx context.Context, name string) (*Pod, error) {
+	pod, err := c.clientset.CoreV1().Pods(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to get pod %s in namespace %s", name, c.namespace)
+	}
+	return toPod(pod), nil
+}
+
+func toPod(pod *corev1.Pod) *Pod {
+	ports := make(map[string]int)
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name != "" {
+				ports[port.Name] = int(port.ContainerPort)
+			}
+		}
+	}
+	return &Pod{
+		Name:           pod.Name,
+		Labels:         pod.GetLabels(),
+		IP:             pod.Status.PodIP,
+		ContainerPorts: ports,
+		StartedAt:      podStartedAt(pod),
+		Ready:          podReady(pod),
+	}
+}
+
+// podStartedAt returns when the most recently started container of the pod started. A restarted container keeps its
+// pod, so the pod's own start time isn't enough.
+func podStartedAt(pod *corev1.Pod) time.Time {
+	var startedAt time.Time
+	if pod.Status.StartTime != nil {
+		startedAt = pod.Status.StartTime.Time
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil && status.State.Running.StartedAt.After(startedAt) {
+			startedAt = status.State.Running.StartedAt.Time
+		}
+	}
+	return startedAt
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// PodStatuses returns the status of every pod in the namespace.
+func (c *KubeClient) PodStatuses(ctx context.Context) ([]*PodStatus, error) {
+	pods, err := c.clientset.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to list pods in namespace %s", c.namespace)
+	}
+
+	statuses := make([]*PodStatus, len(pods.Items))
+	for i, pod := range pods.Items {
+		containers := make(map[string]*ContainerStatus)
+		for _, status := range pod.Status.ContainerStatuses {
+			container := &ContainerStatus{RestartCount: int(status.RestartCount)}
+			if status.State.Waiting != nil {
+				container.WaitingReason = status.State.Waiting.Reason
+			}
+			// a container that is down right now reports its termination in State, otherwise in LastTerminationState
+			terminated := status.State.Terminated
+			if terminated == nil {
+				terminated = status.LastTerminationState.Terminated
+			}
+			if terminated != nil {
+				container.TerminationReason = terminated.Reason
+				container.ExitCode = int(terminated.ExitCode)
+			}
+			containers[status.Name] = container
+		}
+		statuses[i] = &PodStatus{
+			Name:       pod.Name,
+			Labels:     pod.Labels,
+			Phase:      string(pod.Status.Phase),
+			Containers: containers,
+		}
+	}
+	return statuses, nil
+}
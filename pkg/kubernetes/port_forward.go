@@ -12,18 +12,35 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
 type PortForwardsSession struct {
 	stopCh     chan struct{}
+	doneCh     chan struct{}
+	closeOnce  sync.Once
 	Pod        KubePod
 	TargetPort int
 	LocalPort  int
 }
 
+// Close stops the port-forward. It's safe to call more than once.
 func (session *PortForwardsSession) Close() {
-	close(session.stopCh)
+	session.closeOnce.Do(func() {
+		close(session.stopCh)
+	})
+}
+
+// Alive returns whether the port-forward is still running. Port-forwards stop when the connection to the pod breaks,
+// e.g. because the pod was deleted.
+func (session *PortForwardsSession) Alive() bool {
+	select {
+	case <-session.doneCh:
+		return false
+	default:
+		return true
+	}
 }
 
 func (c *KubeClient) StartMultiPortForwardToLabeledPods(
@@ -46,25 +63,38 @@ func (c *KubeClient) StartMultiPortForwards(pods []KubePod, targetPort int) ([]*
 	sessions := make([]*PortForwardsSession, len(pods))
 
 	for i, pod := range pods {
-		localPort, err := getFreeEphemeralPort()
-		if err != nil {
-			return nil, err
-		}
-		stopCh, err := c.StartPortForwarding(pod.GetName(), localPort, targetPort, false)
+		session, err := c.StartPortForwardSession(pod, targetPort)
 		if err != nil {
+			// don't leak the sessions we already started
+			for _, started := range sessions[:i] {
+				started.Close()
+			}
 			return nil, err
 		}
-
-		sessions[i] = &PortForwardsSession{
-			stopCh:     stopCh,
-			Pod:        pod,
-			TargetPort: targetPort,
-			LocalPort:  localPort,
-		}
+		sessions[i] = session
 	}
 	return sessions, nil
 }
 
+// StartPortForwardSession forwards an ephemeral local port to the target port of the pod.
+func (c *KubeClient) StartPortForwardSession(pod KubePod, targetPort int) (*PortForwardsSession, error) {
+	localPort, err := getFreeEphemeralPort()
+	if err != nil {
+		return nil, err
+	}
+	stopCh, doneCh, err := c.startPortForwarding(pod.GetName(), localPort, targetPort, false)
+	if err != nil {
+		return nil, err
+	}
+	return &PortForwardsSession{
+		stopCh:     stopCh,
+		doneCh:     doneCh,
+		Pod:        pod,
+		TargetPort: targetPort,
+		LocalPort:  localPort,
+	}, nil
+}
+
 // getFreeEphemeralPort note: you should use this port immediately otherwise another resource may claim it.
 func getFreeEphemeralPort() (int, error) {
 
@@ -80,7 +110,8 @@ func getFreeEphemeralPort() (int, error) {
 	return port, nil
 }
 
-func openPortForward(target string, dialer httpstream.Dialer, printToStdout bool, retriesRemaining int) (chan struct{}, error) {
+// openPortForward returns a channel that stops the port-forward and a channel that's closed once it has stopped.
+func openPortForward(target string, dialer httpstream.Dialer, printToStdout bool, retriesRemaining int) (chan struct{}, chan struct{}, error) {
 	readyCh := make(chan struct{}, 1)
 	stopCh := make(chan struct{}, 1)
 	errLogger := io.Discard
@@ -90,59 +121,63 @@ func openPortForward(target string, dialer httpstream.Dialer, printToStdout bool
 		errLogger = CreatePrefixWriter("[port-forward] ", logger.WriterLevel(log.ErrorLevel))
 		stdLogger = CreatePrefixWriter("[port-forward] ", logger.WriterLevel(log.InfoLevel))
 	}
-  
+
 	portForward, err := portforward.New(dialer, []string{target}, stopCh, readyCh, stdLogger, errLogger)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "unable to create port forward dialer")
+		return nil, nil, stacktrace.Propagate(err, "unable to create port forward dialer")
 	}
 
+	// buffered so the goroutine never blocks once we stop listening
 	portForwardIssueCh := make(chan error, 1)
-	retryCh := make(chan bool, 1)
-	defer close(portForwardIssueCh)
-	defer close(retryCh)
+	doneCh := make(chan struct{})
 
 	go func() {
-		err = portForward.ForwardPorts()
-		if err != nil {
-			if retriesRemaining == 0 {
-				portForwardIssueCh <- stacktrace.Propagate(err, "unable to start port forward session")
-			} else {
-				retryCh <- true
-			}
-		}
+		err := portForward.ForwardPorts()
+		close(doneCh)
+		portForwardIssueCh <- err
 	}()
 
 	select {
 	case <-readyCh:
-		return stopCh, nil
+		return stopCh, doneCh, nil
 	case err = <-portForwardIssueCh:
-		return nil, err
-	case <-retryCh:
+		if retriesRemaining == 0 {
+			return nil, nil, stacktrace.Propagate(err, "unable to start port forward session")
+		}
 		time.Sleep(200 * time.Millisecond)
 		return openPortForward(target, dialer, printToStdout, retriesRemaining-1)
 	case <-time.After(time.Minute):
-		return nil, errors.New("timed out after waiting to establish port forward")
+		close(stopCh)
+		return nil, nil, errors.New("timed out after waiting to establish port forward")
 	}
 }
 
 func (c *KubeClient) StartPortForwarding(pod string, localPort, remotePort int, printToStdout bool) (stopCh chan struct{}, err error) {
+	stopCh, _, err = c.startPortForwarding(pod, localPort, remotePort, printToStdout)
+	return stopCh, err
+}
+
+func (c *KubeClient) startPortForwarding(pod string, localPort, remotePort int, printToStdout bool) (stopCh, doneCh chan struct{}, err error) {
 	roundTripper, upgrader, err := spdy.RoundTripperFor(c.clientInternal)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "Unable to create roundtripper")
+		return nil, nil, stacktrace.Propagate(err, "Unable to create roundtripper")
 	}
 
 	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", c.namespace, pod)
 	serverURL, err := url.Parse(c.clientInternal.Host)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "unable to decode kubeconfig.Host: %s", c.clientInternal.Host)
+		return nil, nil, stacktrace.Propagate(err, "unable to decode kubeconfig.Host: %s", c.clientInternal.Host)
 	}
 	serverURL.Path = path
 
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, serverURL)
 	target := fmt.Sprintf("%d:%d", localPort, remotePort)
 
-	stopCh, err = openPortForward(target, dialer, printToStdout, 5)
+	stopCh, doneCh, err = openPortForward(target, dialer, printToStdout, 5)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	log.Debugf("Port-forward established to pod/%s:%d", pod, remotePort)
-	return stopCh, err
+	return stopCh, doneCh, nil
 }
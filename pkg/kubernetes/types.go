@@ -1,5 +1,7 @@
 package kubernetes
 
+import "time"
+
 type KubePod interface {
 	GetName() string
 	GetLabels() map[string]string
@@ -9,8 +11,12 @@ type KubePod interface {
 type KubePodList []KubePod
 
 type Pod struct {
-	Name   string
-	Labels map[string]string
+	Name           string
+	Labels         map[string]string
+	IP             string         // empty until the pod is scheduled
+	ContainerPorts map[string]int // named container ports of the pod
+	StartedAt      time.Time      // when the most recently started container of the pod started
+	Ready          bool
 }
 
 func (p *Pod) GetName() string {
@@ -29,3 +35,20 @@ func (p *Pod) MatchesLabel(key, value string) bool {
 		return v == value
 	}
 }
+
+// PodStatus is the state of a pod and its containers at a point in time.
+type PodStatus struct {
+	Name       string
+	Labels     map[string]string
+	Phase      string
+	Containers map[string]*ContainerStatus
+}
+
+type ContainerStatus struct {
+	RestartCount int
+	// why the container is waiting to run, e.g. CrashLoopBackOff
+	WaitingReason string
+	// why the container last terminated, e.g. OOMKilled. empty if it never terminated
+	TerminationReason string
+	ExitCode          int
+}
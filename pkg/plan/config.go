@@ -9,16 +9,15 @@ import (
 
 func validatePlannerFaultConfiguration(c PlannerConfig) error {
 	// fault type
-	_, ok := suite.FaultTypes[c.FaultConfig.FaultType]
-	if !ok {
-		return stacktrace.NewError("the fault type '%s' is not supported. Supported faults: %v", c.FaultConfig.FaultType, suite.FaultTypesList)
+	_, err := suite.LookupFaultType(c.FaultConfig.FaultType)
+	if err != nil {
+		return err
 	}
 
-	// target client
-	// todo
-
-	// intensity domains
-	// todo
+	// intensity domains. entries are validated by DecodeDimensions
+	if len(c.FaultConfig.Dimensions) == 0 {
+		return stacktrace.NewError("at least one fault_config_dimensions entry is required")
+	}
 
 	// targeting dimensions
 	for _, spec := range c.FaultConfig.TargetingDimensions {
@@ -26,18 +25,55 @@ func validatePlannerFaultConfiguration(c PlannerConfig) error {
 		if !ok {
 			return stacktrace.NewError("the fault targeting dimension %s is not supported. Supported dimensions: %v", spec, suite.TargetingSpecList)
 		}
+		if (spec == suite.TargetMatchingMevBoost || spec == suite.TargetMatchingRelay) && (c.NetworkFeatures.MevType == "" || c.NetworkFeatures.MevType == "null") {
+			return stacktrace.NewError("the fault targeting dimension %s requires network_features.mev_type to be set", spec)
+		}
+		if spec == suite.TargetMatchingRelay {
+			_, err = suite.RelayServiceNames(c.NetworkFeatures.MevType)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// attack size dimensions
 	for _, attackSize := range c.FaultConfig.AttackSizeDimensions {
 		_, ok := suite.AttackSizes[attackSize]
-		if !ok {
-			return stacktrace.NewError("the attack size dimension %s is not supported. Supported dimensions: %v", attackSize, suite.AttackSizesList)
+		if ok {
+			continue
+		}
+		_, isStake, err := suite.ParseStakeAttackSize(attackSize)
+		if err != nil {
+			return err
+		}
+		if !isStake {
+			return stacktrace.NewError("the attack size dimension %s is not supported. Supported dimensions: %v, or %s<fraction>", attackSize, suite.AttackSizesList, suite.AttackSizeStakePrefix)
 		}
 	}
 
-	// target client
-	if c.FaultConfig.TargetClient != "all" {
+	// stake fraction policy. defaults to exact
+	_, ok := suite.StakeFractionPolicies[c.FaultConfig.StakeFractionPolicy]
+	if c.FaultConfig.StakeFractionPolicy != "" && !ok {
+		return stacktrace.NewError("the stake fraction policy %s is not supported. Supported policies: %s, %s", c.FaultConfig.StakeFractionPolicy, suite.StakeFractionExact, suite.StakeFractionClosest)
+	}
+
+	// target selection. defaults to first
+	_, ok = suite.TargetSelectionModes[c.FaultConfig.TargetSelection]
+	if c.FaultConfig.TargetSelection != "" && !ok {
+		return stacktrace.NewError("the target selection mode %s is not supported. Supported modes: %v", c.FaultConfig.TargetSelection, suite.TargetSelectionModesList)
+	}
+	if c.FaultConfig.MaxTargetCombinations < 0 {
+		return stacktrace.NewError("max_target_combinations must be >= 0")
+	}
+
+	// target client. targeting every client isn't supported
+	if c.FaultConfig.TargetClient == "" || c.FaultConfig.TargetClient == "all" {
+		return stacktrace.NewError("target_client must name a single client, got '%s'", c.FaultConfig.TargetClient)
+	}
+	// planner configs used against an existing network config may omit the client definitions, in which case the
+	// target client is checked against the network's participants instead.
+	hasClientDefinitions := len(c.ExecutionClients) > 0 || len(c.ConsensusClients) > 0
+	if hasClientDefinitions {
 		if !c.IsTargetExecutionClient() && !c.IsTargetConsensusClient() {
 			return stacktrace.NewError("target_client %s is not defined in the execution/consensus client configuration", c.FaultConfig.TargetClient)
 		}
@@ -58,6 +94,11 @@ func LoadPlannerConfigFromPath(path string) (*PlannerConfig, error) {
 		return nil, stacktrace.Propagate(err, "unable to unmarshal planner config from %s", path)
 	}
 
+	err = config.FaultConfig.DecodeDimensions()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "invalid fault_config in planner config %s", path)
+	}
+
 	err = validatePlannerFaultConfiguration(config)
 	if err != nil {
 		return nil, err
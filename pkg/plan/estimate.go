@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"attacknet/cmd/pkg/plan/network"
+	"attacknet/cmd/pkg/types"
+	"fmt"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	// the ethereum-package's default for network_params.genesis_delay, in seconds
+	defaultKurtosisGenesisDelay = 120
+	defaultStepOverhead         = 15 * time.Second
+)
+
+// EstimateConfig tunes the runtime and resource estimate printed by the planner.
+type EstimateConfig struct {
+	StepOverhead  *time.Duration `yaml:"step_overhead,omitempty"` // time spent injecting/removing a fault or running a health check round
+	ClusterCpu    int            `yaml:"cluster_cpu,omitempty"`   // millicores available to the network. 0 disables the check
+	ClusterMemory int            `yaml:"cluster_mem,omitempty"`   // MB available to the network. 0 disables the check
+}
+
+type TestEstimate struct {
+	TestName  string        `yaml:"test_name"`
+	Minimum   time.Duration `yaml:"minimum"`
+	WorstCase time.Duration `yaml:"worst_case"`
+}
+
+type ResourceEstimate struct {
+	CpuRequired    int `yaml:"cpu_required"`
+	MemoryRequired int `yaml:"mem_required"`
+	ClusterCpu     int `yaml:"cluster_cpu,omitempty"`
+	ClusterMemory  int `yaml:"cluster_mem,omitempty"`
+}
+
+type PlanEstimate struct {
+	// time between the network starting and the first fault being injected
+	Setup          time.Duration    `yaml:"setup"`
+	Tests          []TestEstimate   `yaml:"tests"`
+	TotalMinimum   time.Duration    `yaml:"total_minimum"`
+	TotalWorstCase time.Duration    `yaml:"total_worst_case"`
+	Resources      ResourceEstimate `yaml:"resources"`
+	Warnings       []string         `yaml:"warnings,omitempty"`
+}
+
+// EstimatePlan estimates how long a suite will take to run and how many resources its network requests. The minimum
+// assumes health checks pass on the first round after each fault. The worst case assumes every grace period is used
+// up.
+func EstimatePlan(config *PlannerConfig, genesis *network.GenesisConfig, nodes []*network.Node, tests []types.SuiteTest) (*PlanEstimate, error) {
+	stepOverhead := defaultStepOverhead
+	if config.Estimate.StepOverhead != nil {
+		stepOverhead = *config.Estimate.StepOverhead
+	}
+
+	// the wait before the first test starts at the same time as the genesis delay
+	genesisDelay := time.Duration(defaultKurtosisGenesisDelay) * time.Second
+	if genesis.GenesisDelay != nil {
+		genesisDelay = time.Duration(*genesis.GenesisDelay) * time.Second
+	}
+	estimate := &PlanEstimate{Setup: max(genesisDelay, config.FaultConfig.WaitBeforeFirstTest)}
+	estimate.TotalMinimum = estimate.Setup
+	estimate.TotalWorstCase = estimate.Setup
+
+	for _, test := range tests {
+		testEstimate, err := estimateTest(test, stepOverhead)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Tests = append(estimate.Tests, *testEstimate)
+		estimate.TotalMinimum += testEstimate.Minimum
+		estimate.TotalWorstCase += testEstimate.WorstCase
+	}
+
+	estimate.Resources = estimateResources(nodes)
+	estimate.Resources.ClusterCpu = config.Estimate.ClusterCpu
+	estimate.Resources.ClusterMemory = config.Estimate.ClusterMemory
+	if config.Estimate.ClusterCpu > 0 && estimate.Resources.CpuRequired > config.Estimate.ClusterCpu {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("the network requests %dm cpu but the cluster only has %dm", estimate.Resources.CpuRequired, config.Estimate.ClusterCpu))
+	}
+	if config.Estimate.ClusterMemory > 0 && estimate.Resources.MemoryRequired > config.Estimate.ClusterMemory {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("the network requests %dMB memory but the cluster only has %dMB", estimate.Resources.MemoryRequired, config.Estimate.ClusterMemory))
+	}
+	for _, warning := range estimate.Warnings {
+		log.Warn(warning)
+	}
+
+	return estimate, nil
+}
+
+func estimateTest(test types.SuiteTest, stepOverhead time.Duration) (*TestEstimate, error) {
+	// faults are injected back-to-back and run concurrently, so the test lasts as long as its longest fault
+	var longestFault time.Duration
+	var waits time.Duration
+	for _, step := range test.PlanSteps {
+		switch step.StepType {
+		case types.InjectFault:
+			d, err := faultDuration(step)
+			if err != nil {
+				return nil, stacktrace.Propagate(err, "unable to estimate the duration of test '%s'", test.TestName)
+			}
+			longestFault = max(longestFault, d)
+		case types.WaitForDuration:
+			d, err := stepWaitDuration(step)
+			if err != nil {
+				return nil, stacktrace.Propagate(err, "unable to estimate the duration of test '%s'", test.TestName)
+			}
+			waits += d
+		}
+	}
+
+	minimum := longestFault + waits + time.Duration(len(test.PlanSteps))*stepOverhead
+	worstCase := minimum
+	if test.HealthConfig.EnableChecks {
+		minimum += stepOverhead
+		worstCase += stepOverhead
+		if test.HealthConfig.GracePeriod != nil {
+			worstCase += *test.HealthConfig.GracePeriod
+		}
+	}
+	return &TestEstimate{TestName: test.TestName, Minimum: minimum, WorstCase: worstCase}, nil
+}
+
+func faultDuration(step types.PlanStep) (time.Duration, error) {
+	faultSpec, ok := step.Spec["chaosFaultSpec"].(map[string]interface{})
+	if !ok {
+		return 0, stacktrace.NewError("step '%s' is missing a chaosFaultSpec", step.StepDescription)
+	}
+	spec, ok := faultSpec["spec"].(map[string]interface{})
+	if !ok {
+		return 0, stacktrace.NewError("step '%s' is missing a fault spec", step.StepDescription)
+	}
+	duration, ok := spec["duration"].(string)
+	if !ok {
+		// faults without a duration are treated as instant
+		return 0, nil
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "unable to parse duration of step '%s'", step.StepDescription)
+	}
+	return d, nil
+}
+
+func stepWaitDuration(step types.PlanStep) (time.Duration, error) {
+	duration, ok := step.Spec["duration"].(string)
+	if !ok {
+		return 0, stacktrace.NewError("step '%s' is missing a duration", step.StepDescription)
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "unable to parse duration of step '%s'", step.StepDescription)
+	}
+	return d, nil
+}
+
+func estimateResources(nodes []*network.Node) ResourceEstimate {
+	var resources ResourceEstimate
+	for _, node := range nodes {
+		resources.CpuRequired += node.Execution.CpuRequired + node.Consensus.CpuRequired
+		resources.MemoryRequired += node.Execution.MemoryRequired + node.Consensus.MemoryRequired
+		if node.Consensus.HasValidatorSidecar {
+			resources.CpuRequired += node.Consensus.SidecarCpuRequired
+			resources.MemoryRequired += node.Consensus.SidecarMemoryRequired
+		}
+	}
+	return resources
+}
@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"attacknet/cmd/pkg/plan/network"
+	"attacknet/cmd/pkg/types"
+	"testing"
+	"time"
+)
+
+func injectStep(duration string) types.PlanStep {
+	spec := map[string]interface{}{}
+	if duration != "" {
+		spec["duration"] = duration
+	}
+	return types.PlanStep{
+		StepType: types.InjectFault,
+		Spec: map[string]interface{}{
+			"chaosFaultSpec": map[string]interface{}{"spec": spec},
+		},
+	}
+}
+
+func waitStep(duration string) types.PlanStep {
+	return types.PlanStep{StepType: types.WaitForDuration, Spec: map[string]interface{}{"duration": duration}}
+}
+
+func TestEstimateTest(t *testing.T) {
+	gracePeriod := 5 * time.Minute
+	overhead := 10 * time.Second
+	type testCase struct {
+		name      string
+		test      types.SuiteTest
+		minimum   time.Duration
+		worstCase time.Duration
+	}
+	testCases := []testCase{
+		{
+			name: "single fault without checks",
+			test: types.SuiteTest{PlanSteps: []types.PlanStep{
+				injectStep("1m"),
+				{StepType: types.WaitForFaultCompletion},
+			}},
+			// fault + 2 steps of overhead
+			minimum:   time.Minute + 20*time.Second,
+			worstCase: time.Minute + 20*time.Second,
+		},
+		{
+			name: "concurrent faults with checks",
+			test: types.SuiteTest{
+				PlanSteps: []types.PlanStep{
+					injectStep("1m"),
+					injectStep("3m"),
+					{StepType: types.WaitForFaultCompletion},
+				},
+				HealthConfig: types.HealthCheckConfig{EnableChecks: true, GracePeriod: &gracePeriod},
+			},
+			// longest fault + 3 steps of overhead + 1 health check round, plus the grace period in the worst case
+			minimum:   3*time.Minute + 40*time.Second,
+			worstCase: 8*time.Minute + 40*time.Second,
+		},
+		{
+			name: "waits add up",
+			test: types.SuiteTest{PlanSteps: []types.PlanStep{
+				injectStep(""),
+				waitStep("30s"),
+				waitStep("45s"),
+			}},
+			minimum:   75*time.Second + 30*time.Second,
+			worstCase: 75*time.Second + 30*time.Second,
+		},
+		{
+			name: "checks without a grace period",
+			test: types.SuiteTest{
+				PlanSteps:    []types.PlanStep{injectStep("2m")},
+				HealthConfig: types.HealthCheckConfig{EnableChecks: true},
+			},
+			minimum:   2*time.Minute + 20*time.Second,
+			worstCase: 2*time.Minute + 20*time.Second,
+		},
+	}
+
+	for _, test := range testCases {
+		estimate, err := estimateTest(test.test, overhead)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if estimate.Minimum != test.minimum || estimate.WorstCase != test.worstCase {
+			t.Errorf("%s: expected %s/%s, got %s/%s", test.name, test.minimum, test.worstCase, estimate.Minimum, estimate.WorstCase)
+		}
+	}
+
+	_, err := estimateTest(types.SuiteTest{PlanSteps: []types.PlanStep{injectStep("soon")}}, overhead)
+	if err == nil {
+		t.Fatal("expected an unparseable fault duration to fail")
+	}
+	_, err = estimateTest(types.SuiteTest{PlanSteps: []types.PlanStep{{StepType: types.InjectFault, Spec: map[string]interface{}{}}}}, overhead)
+	if err == nil {
+		t.Fatal("expected a fault without a chaosFaultSpec to fail")
+	}
+}
+
+func TestEstimatePlan(t *testing.T) {
+	overhead := 10 * time.Second
+	gracePeriod := time.Minute
+	config := &PlannerConfig{Estimate: EstimateConfig{StepOverhead: &overhead, ClusterCpu: 4000, ClusterMemory: 100000}}
+	config.FaultConfig.WaitBeforeFirstTest = 5 * time.Minute
+	genesisDelay := 60
+	genesis := &network.GenesisConfig{GenesisDelay: &genesisDelay}
+
+	nodes := []*network.Node{
+		{
+			Execution: &network.ExecutionClient{CpuRequired: 1000, MemoryRequired: 1024},
+			Consensus: &network.ConsensusClient{CpuRequired: 1000, MemoryRequired: 2048, HasValidatorSidecar: true, SidecarCpuRequired: 500, SidecarMemoryRequired: 512},
+		},
+		{
+			// sidecar resources are ignored without a sidecar
+			Execution: &network.ExecutionClient{CpuRequired: 1000, MemoryRequired: 1024},
+			Consensus: &network.ConsensusClient{CpuRequired: 1000, MemoryRequired: 2048, SidecarCpuRequired: 500, SidecarMemoryRequired: 512},
+		},
+	}
+	tests := []types.SuiteTest{
+		{TestName: "a", PlanSteps: []types.PlanStep{injectStep("1m")}},
+		{TestName: "b", PlanSteps: []types.PlanStep{injectStep("2m")}, HealthConfig: types.HealthCheckConfig{EnableChecks: true, GracePeriod: &gracePeriod}},
+	}
+
+	estimate, err := EstimatePlan(config, genesis, nodes, tests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the wait before the first test is longer than the genesis delay
+	if estimate.Setup != 5*time.Minute {
+		t.Fatalf("expected a setup of 5m, got %s", estimate.Setup)
+	}
+	if len(estimate.Tests) != 2 || estimate.Tests[0].TestName != "a" {
+		t.Fatalf("unexpected test estimates %+v", estimate.Tests)
+	}
+	// 5m + (1m10s) + (2m20s)
+	if estimate.TotalMinimum != 8*time.Minute+30*time.Second {
+		t.Fatalf("expected a total minimum of 8m30s, got %s", estimate.TotalMinimum)
+	}
+	if estimate.TotalWorstCase != 9*time.Minute+30*time.Second {
+		t.Fatalf("expected a total worst case of 9m30s, got %s", estimate.TotalWorstCase)
+	}
+	if estimate.Resources.CpuRequired != 4500 || estimate.Resources.MemoryRequired != 6656 {
+		t.Fatalf("unexpected resources %+v", estimate.Resources)
+	}
+	// only the cpu exceeds the cluster
+	if len(estimate.Warnings) != 1 {
+		t.Fatalf("expected a cpu warning, got %v", estimate.Warnings)
+	}
+
+	// without a wait or genesis delay, the ethereum-package's default genesis delay applies
+	config = &PlannerConfig{}
+	estimate, err = EstimatePlan(config, &network.GenesisConfig{}, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Setup != defaultKurtosisGenesisDelay*time.Second || estimate.TotalMinimum != estimate.Setup || len(estimate.Warnings) != 0 {
+		t.Fatalf("unexpected default estimate %+v", estimate)
+	}
+}
@@ -1,75 +1,168 @@
 package plan
 
 import (
+	"attacknet/cmd/pkg/types"
 	"fmt"
 	"github.com/kurtosis-tech/stacktrace"
+	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"regexp"
 )
 
-func preparePaths(testName string) (netRefPath, netConfigPath, planConfigPath string, err error) {
-	dir, err := os.Getwd()
-	// initialize to empty string for error cases
-	netConfigPath = ""
-	planConfigPath = ""
-	if err != nil {
+// OutputOptions controls where and how generated plans are written.
+type OutputOptions struct {
+	Dir   string // project directory to write the plan into. Defaults to the working directory.
+	Force bool   // overwrite existing plans with the same name
+	Split int    // number of test suites to split the generated tests across. They share one network config.
+
+	EstimateOnly bool // print a runtime and resource estimate instead of writing the plan
+}
+
+// preparePaths resolves where the plan is written. Suites left behind by an earlier plan with the same name but a
+// different --split are returned as stalePaths, so they can be removed once the new plan is written.
+func preparePaths(testName string, opts OutputOptions) (netRefPath, netConfigPath string, suiteConfigPaths, stalePaths []string, err error) {
+	dir := opts.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return
+		}
+	}
+	if opts.Split < 1 {
+		err = stacktrace.NewError("the number of suites to split the plan into must be >= 1, got %d", opts.Split)
 		return
 	}
 
 	netRefPath = fmt.Sprintf("plan/%s.yaml", testName)
-	networkConfigName := fmt.Sprintf("network-configs/%s", netRefPath)
-	netConfigPath = filepath.Join(dir, networkConfigName)
-	if _, err = os.Stat(netConfigPath); err == nil {
-		// delete file
-		err = os.Remove(netConfigPath)
+	netConfigPath = filepath.Join(dir, "network-configs", netRefPath)
+	err = prepareOutputFile(netConfigPath, opts.Force)
+	if err != nil {
+		return
+	}
+
+	for i := 1; i <= opts.Split; i++ {
+		suiteName := testName
+		if opts.Split > 1 {
+			suiteName = fmt.Sprintf("%s-%d", testName, i)
+		}
+		suiteConfigPath := filepath.Join(dir, "test-suites", "plan", fmt.Sprintf("%s.yaml", suiteName))
+		err = prepareOutputFile(suiteConfigPath, opts.Force)
 		if err != nil {
-			err = stacktrace.Propagate(err, "unable to remove file")
 			return
 		}
+		suiteConfigPaths = append(suiteConfigPaths, suiteConfigPath)
 	}
 
-	suiteName := fmt.Sprintf("test-suites/plan/%s.yaml", testName)
-	planConfigPath = filepath.Join(dir, suiteName)
-	if _, err = os.Stat(planConfigPath); err == nil {
-		// delete file
-		err = os.Remove(planConfigPath)
+	stalePaths, err = findStaleSuites(testName, netRefPath, suiteConfigPaths)
+	if err != nil {
+		return
+	}
+	if len(stalePaths) > 0 && !opts.Force {
+		err = stacktrace.NewError("suites from an earlier plan named %s exist: %v. Use --force to remove them", testName, stalePaths)
+	}
+	return
+}
+
+// findStaleSuites lists the existing suites of a plan, split or not, that the new plan won't overwrite. Every suite of
+// a plan points at the plan's network config, so suites of other plans with a similar name, e.g. a plan named geth-1
+// next to a split plan named geth, are left alone.
+func findStaleSuites(testName, netRefPath string, suiteConfigPaths []string) ([]string, error) {
+	dir := filepath.Dir(suiteConfigPaths[0])
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to list %s", dir)
+	}
+	planned := make(map[string]bool)
+	for _, path := range suiteConfigPaths {
+		planned[filepath.Base(path)] = true
+	}
+	splitSuite := regexp.MustCompile(fmt.Sprintf(`^%s-[0-9]+\.yaml$`, regexp.QuoteMeta(testName)))
+
+	var stale []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if planned[name] || entry.IsDir() {
+			continue
+		}
+		if name != testName+".yaml" && !splitSuite.MatchString(name) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		belongsToPlan, err := suiteUsesNetworkConfig(path, netRefPath)
 		if err != nil {
-			err = stacktrace.Propagate(err, "unable to remove file")
-			return
+			return nil, err
+		}
+		if belongsToPlan {
+			stale = append(stale, path)
 		}
 	}
-	err = nil
-	return
+	return stale, nil
 }
 
-func writePlans(netConfigPath, suiteConfigPath string, netConfig, suiteConfig []byte) error {
-	f, err := os.Create(netConfigPath)
+func suiteUsesNetworkConfig(path, netRefPath string) (bool, error) {
+	bs, err := os.ReadFile(path)
 	if err != nil {
-		return stacktrace.Propagate(err, "cannot open network types path %s", netConfigPath)
+		return false, stacktrace.Propagate(err, "unable to read suite %s", path)
+	}
+	var suiteConfig types.Config
+	// files that aren't suites can't belong to the plan
+	if yaml.Unmarshal(bs, &suiteConfig) != nil {
+		return false, nil
+	}
+	return suiteConfig.HarnessConfig.NetworkConfigPath == netRefPath, nil
+}
+
+// prepareOutputFile makes sure path can be written to, creating its parent directory. Existing files are only
+// overwritten when force is set.
+func prepareOutputFile(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return stacktrace.NewError("%s already exists. Use --force to overwrite it", path)
 	}
-	_, err = f.Write(netConfig)
+
+	err := os.MkdirAll(filepath.Dir(path), 0750)
 	if err != nil {
-		return stacktrace.Propagate(err, "could not write network types to file")
+		return stacktrace.Propagate(err, "unable to create directory for %s", path)
 	}
+	return nil
+}
 
-	err = f.Close()
+func writePlans(netConfigPath string, suiteConfigPaths, stalePaths []string, netConfig []byte, suiteConfigs [][]byte) error {
+	err := writePlanFile(netConfigPath, netConfig)
 	if err != nil {
-		return stacktrace.Propagate(err, "could not close network types file")
+		return stacktrace.Propagate(err, "could not write network types to file")
 	}
 
-	f, err = os.Create(suiteConfigPath)
+	for i, suiteConfigPath := range suiteConfigPaths {
+		err = writePlanFile(suiteConfigPath, suiteConfigs[i])
+		if err != nil {
+			return stacktrace.Propagate(err, "could not write suite types to file")
+		}
+	}
+
+	for _, stalePath := range stalePaths {
+		err = os.Remove(stalePath)
+		if err != nil {
+			return stacktrace.Propagate(err, "could not remove stale suite %s", stalePath)
+		}
+	}
+
+	return nil
+}
+
+func writePlanFile(path string, contents []byte) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return stacktrace.Propagate(err, "cannot open suite types path %s", suiteConfigPath)
+		return stacktrace.Propagate(err, "cannot open path %s", path)
 	}
-	_, err = f.Write(suiteConfig)
+	_, err = f.Write(contents)
 	if err != nil {
-		return stacktrace.Propagate(err, "could not write suite types to file")
+		return stacktrace.Propagate(err, "could not write to %s", path)
 	}
 
 	err = f.Close()
 	if err != nil {
-		return stacktrace.Propagate(err, "could not close suite types file")
+		return stacktrace.Propagate(err, "could not close %s", path)
 	}
-
 	return nil
 }
@@ -0,0 +1,192 @@
+package plan
+
+import (
+	"attacknet/cmd/pkg/types"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitTests(t *testing.T) {
+	type testCase struct {
+		tests         int
+		n             int
+		expectedSizes []int
+	}
+	testCases := []testCase{
+		{tests: 6, n: 1, expectedSizes: []int{6}},
+		{tests: 6, n: 3, expectedSizes: []int{2, 2, 2}},
+		{tests: 7, n: 3, expectedSizes: []int{3, 2, 2}},
+		{tests: 8, n: 3, expectedSizes: []int{3, 3, 2}},
+		{tests: 3, n: 3, expectedSizes: []int{1, 1, 1}},
+	}
+
+	for _, test := range testCases {
+		tests := make([]types.SuiteTest, test.tests)
+		for i := range tests {
+			tests[i].TestName = fmt.Sprint(i)
+		}
+		groups := splitTests(tests, test.n)
+		if len(groups) != len(test.expectedSizes) {
+			t.Fatalf("%d into %d: expected %d groups, got %d", test.tests, test.n, len(test.expectedSizes), len(groups))
+		}
+		next := 0
+		for i, group := range groups {
+			if len(group) != test.expectedSizes[i] {
+				t.Fatalf("%d into %d: expected group %d to have %d tests, got %d", test.tests, test.n, i, test.expectedSizes[i], len(group))
+			}
+			// groups are contiguous and keep the test order
+			for _, suiteTest := range group {
+				if suiteTest.TestName != fmt.Sprint(next) {
+					t.Fatalf("%d into %d: expected test %d, got %s", test.tests, test.n, next, suiteTest.TestName)
+				}
+				next++
+			}
+		}
+	}
+}
+
+// writeExistingPlan writes files of an earlier plan named test. Suites point at the plan's network config.
+func writeExistingPlan(t *testing.T, dir string, paths ...string) {
+	writeExistingPlanNamed(t, dir, "test", paths...)
+}
+
+func writeExistingPlanNamed(t *testing.T, dir, planName string, paths ...string) {
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		contents := fmt.Sprintf("harnessConfig:\n  networkConfig: plan/%s.yaml\n", planName)
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPreparePaths(t *testing.T) {
+	type testCase struct {
+		name          string
+		existing      []string
+		split         int
+		force         bool
+		expectError   bool
+		expectedStale []string
+		otherPlans    []string // suites of a separate plan named test-1
+	}
+	testCases := []testCase{
+		{name: "new plan", split: 1},
+		{name: "new split plan", split: 3},
+		{name: "network config exists", existing: []string{"network-configs/plan/test.yaml"}, split: 1, expectError: true},
+		{name: "suite exists", existing: []string{"test-suites/plan/test.yaml"}, split: 1, expectError: true},
+		{name: "exists with force", existing: []string{"network-configs/plan/test.yaml", "test-suites/plan/test.yaml"}, split: 1, force: true},
+		{name: "smaller split", existing: []string{"test-suites/plan/test-1.yaml", "test-suites/plan/test-2.yaml", "test-suites/plan/test-3.yaml"}, split: 2, expectError: true},
+		// only the suite the new plan doesn't overwrite exists
+		{name: "stale suite", existing: []string{"test-suites/plan/test-3.yaml"}, split: 2, expectError: true},
+		{
+			name:          "smaller split with force",
+			existing:      []string{"test-suites/plan/test-1.yaml", "test-suites/plan/test-2.yaml", "test-suites/plan/test-3.yaml"},
+			split:         2,
+			force:         true,
+			expectedStale: []string{"test-suites/plan/test-3.yaml"},
+		},
+		{
+			name:          "unsplit after split with force",
+			existing:      []string{"test-suites/plan/test-1.yaml", "test-suites/plan/test-2.yaml"},
+			split:         1,
+			force:         true,
+			expectedStale: []string{"test-suites/plan/test-1.yaml", "test-suites/plan/test-2.yaml"},
+		},
+		{
+			name:          "split after unsplit with force",
+			existing:      []string{"test-suites/plan/test.yaml"},
+			split:         2,
+			force:         true,
+			expectedStale: []string{"test-suites/plan/test.yaml"},
+		},
+		// other plans in the same directory are left alone
+		{name: "other plans", existing: []string{"test-suites/plan/test-other.yaml", "test-suites/plan/testing.yaml"}, split: 2},
+		// a separate plan whose name looks like a split suite of this one
+		{name: "plan named like a split suite", otherPlans: []string{"test-suites/plan/test-1.yaml"}, split: 1},
+		{name: "plan named like a split suite with force", otherPlans: []string{"test-suites/plan/test-1.yaml"}, split: 1, force: true},
+		{
+			name:          "plan named like a split suite next to stale suites",
+			existing:      []string{"test-suites/plan/test-2.yaml"},
+			otherPlans:    []string{"test-suites/plan/test-1.yaml"},
+			split:         1,
+			force:         true,
+			expectedStale: []string{"test-suites/plan/test-2.yaml"},
+		},
+		{name: "invalid split", split: 0, expectError: true},
+	}
+
+	for _, test := range testCases {
+		dir := t.TempDir()
+		if len(test.existing) > 0 {
+			writeExistingPlan(t, dir, test.existing...)
+		}
+		if len(test.otherPlans) > 0 {
+			writeExistingPlanNamed(t, dir, "test-1", test.otherPlans...)
+		}
+		_, netConfigPath, suitePaths, stalePaths, err := preparePaths("test", OutputOptions{Dir: dir, Split: test.split, Force: test.force})
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.name, err)
+			continue
+		}
+		if netConfigPath != filepath.Join(dir, "network-configs/plan/test.yaml") || len(suitePaths) != test.split {
+			t.Errorf("%s: unexpected paths %s %v", test.name, netConfigPath, suitePaths)
+		}
+		if len(stalePaths) != len(test.expectedStale) {
+			t.Errorf("%s: expected stale suites %v, got %v", test.name, test.expectedStale, stalePaths)
+			continue
+		}
+		for i, stale := range test.expectedStale {
+			if stalePaths[i] != filepath.Join(dir, stale) {
+				t.Errorf("%s: expected stale suites %v, got %v", test.name, test.expectedStale, stalePaths)
+			}
+		}
+	}
+}
+
+func TestWritePlansRemovesStaleSuites(t *testing.T) {
+	dir := t.TempDir()
+	writeExistingPlan(t, dir, "test-suites/plan/test-1.yaml", "test-suites/plan/test-2.yaml", "test-suites/plan/test-3.yaml")
+	_, netConfigPath, suitePaths, stalePaths, err := preparePaths("test", OutputOptions{Dir: dir, Split: 2, Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writePlans(netConfigPath, suitePaths, stalePaths, []byte("net"), [][]byte{[]byte("suite 1"), []byte("suite 2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(suitePaths[1])
+	if err != nil || string(bs) != "suite 2" {
+		t.Fatalf("expected test-2.yaml to be overwritten, got %q %v", bs, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test-suites/plan/test-3.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale test-3.yaml to be removed, got %v", err)
+	}
+}
+
+func TestWritePlansKeepsOtherPlans(t *testing.T) {
+	dir := t.TempDir()
+	writeExistingPlanNamed(t, dir, "test-1", "network-configs/plan/test-1.yaml", "test-suites/plan/test-1.yaml")
+	_, netConfigPath, suitePaths, stalePaths, err := preparePaths("test", OutputOptions{Dir: dir, Split: 1, Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writePlans(netConfigPath, suitePaths, stalePaths, []byte("net"), [][]byte{[]byte("suite")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test-suites/plan/test-1.yaml")); err != nil {
+		t.Fatalf("expected the suite of the plan named test-1 to be kept, got %v", err)
+	}
+}
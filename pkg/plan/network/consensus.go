@@ -11,17 +11,26 @@ const defaultValCpu = 500
 const defaultClMem = 1536
 const defaultValMem = 512
 
-func composeConsensusTesterNetwork(nodeMultiplier int, consensusClient string, execClientList []ClientVersion, consClientMap map[string]ClientVersion) ([]*Node, error) {
-	// make sure consensusClient actually exists
-	clientUnderTest, ok := consClientMap[consensusClient]
-	if !ok {
-		return nil, stacktrace.NewError("unknown consensus client %s", consensusClient)
-	}
-
+func composeConsensusTesterNetwork(nodeMultiplier int, consensusClient string, execClientList []ClientVersion, consClientList []ClientVersion) ([]*Node, error) {
 	// start from 2 because bootnode is index 1
 	index := 2
-	nodes, err := composeNodesForClTesting(nodeMultiplier, index, clientUnderTest, execClientList)
-	return nodes, err
+	var nodes []*Node
+	// every version of the client under test gets tested, unless the target is a specific version
+	for _, clientUnderTest := range consClientList {
+		if !MatchesClient(clientUnderTest.Name, clientUnderTest.Version, consensusClient) {
+			continue
+		}
+		versionNodes, err := composeNodesForClTesting(nodeMultiplier, index, clientUnderTest, execClientList)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, versionNodes...)
+		index += len(versionNodes)
+	}
+	if len(nodes) == 0 {
+		return nil, stacktrace.NewError("unknown consensus client %s", consensusClient)
+	}
+	return nodes, nil
 }
 
 func composeNodesForClTesting(nodeMultiplier, index int, consensusClient ClientVersion, execClients []ClientVersion) ([]*Node, error) {
@@ -47,29 +56,32 @@ func composeConsensusClient(config ClientVersion) *ConsensusClient {
 		image = images[0]
 		validatorImage = images[1]
 	}
+	client := &ConsensusClient{
+		Type:                config.Name,
+		Version:             config.Version,
+		Image:               image,
+		HasValidatorSidecar: config.HasSidecar,
+		ValidatorImage:      validatorImage,
+		ExtraLabels:         make(map[string]string),
+		ExtraParams:         append([]string{}, config.ExtraParams...),
+		CpuRequired:         defaultClCpu,
+		CpuLimit:            defaultClCpu,
+		MemoryRequired:      defaultClMem,
+		MemoryLimit:         defaultClMem,
+		StorageSize:         config.Resources.StorageSize,
+	}
+	applyResourceProfile(config.Resources, &client.CpuRequired, &client.CpuLimit, &client.MemoryRequired, &client.MemoryLimit)
+	if config.Version != "" {
+		client.ExtraLabels[ClientVersionLabel] = config.Version
+	}
+
 	if config.HasSidecar {
-		return &ConsensusClient{
-			Type:                  config.Name,
-			Image:                 image,
-			HasValidatorSidecar:   true,
-			ValidatorImage:        validatorImage,
-			ExtraLabels:           make(map[string]string),
-			CpuRequired:           defaultClCpu,
-			MemoryRequired:        defaultClMem,
-			SidecarCpuRequired:    defaultValCpu,
-			SidecarMemoryRequired: defaultValMem,
-		}
-	} else {
-		return &ConsensusClient{
-			Type:                  config.Name,
-			Image:                 image,
-			HasValidatorSidecar:   false,
-			ValidatorImage:        validatorImage,
-			ExtraLabels:           make(map[string]string),
-			CpuRequired:           defaultClCpu,
-			MemoryRequired:        defaultClMem,
-			SidecarCpuRequired:    0,
-			SidecarMemoryRequired: 0,
-		}
+		client.ValidatorExtraParams = append([]string{}, config.ValidatorParams...)
+		client.SidecarCpuRequired = defaultValCpu
+		client.SidecarCpuLimit = defaultValCpu
+		client.SidecarMemoryRequired = defaultValMem
+		client.SidecarMemoryLimit = defaultValMem
+		applyResourceProfile(config.ValidatorResources, &client.SidecarCpuRequired, &client.SidecarCpuLimit, &client.SidecarMemoryRequired, &client.SidecarMemoryLimit)
 	}
+	return client
 }
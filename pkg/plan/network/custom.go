@@ -0,0 +1,102 @@
+package network
+
+import (
+	"github.com/kurtosis-tech/stacktrace"
+)
+
+func composeCustomTopology(topology Topology, clientUnderTest string, execClients, consClients map[string]ClientVersion, valKeysPerNode int) ([]*Node, error) {
+	if topology.BootnodeEL != "" || topology.BootnodeCl != "" || topology.TargetsAsPercentOfNetwork != 0 || topology.TargetNodeMultiplier != 0 {
+		return nil, stacktrace.NewError("topology.nodes cannot be combined with bootnode_el, bootnode_cl, targets_as_percent_of_network or target_node_multiplier. The first node in topology.nodes is used as the bootnode")
+	}
+
+	var nodes []*Node
+	index := 1
+	for i, spec := range topology.Nodes {
+		execConf, ok := execClients[spec.Execution]
+		if !ok {
+			return nil, stacktrace.NewError("unable to load configuration for exec client %s in topology node %d", spec.Execution, i)
+		}
+		consConf, ok := consClients[spec.Consensus]
+		if !ok {
+			return nil, stacktrace.NewError("unable to load configuration for consensus client %s in topology node %d", spec.Consensus, i)
+		}
+		if spec.Count < 0 {
+			return nil, stacktrace.NewError("count for topology node %d must be >= 0", i)
+		}
+		if spec.ValidatorCount != nil && *spec.ValidatorCount < 0 {
+			return nil, stacktrace.NewError("validator_count for topology node %d must be >= 0", i)
+		}
+		for _, profile := range []ResourceProfile{spec.ElResources, spec.ClResources, spec.VcResources} {
+			if err := validateResourceProfile(profile); err != nil {
+				return nil, stacktrace.Propagate(err, "invalid resources for topology node %d", i)
+			}
+		}
+
+		count := spec.Count
+		if count == 0 {
+			count = 1
+		}
+		for j := 0; j < count; j++ {
+			node := buildNode(index, execConf, consConf)
+			applyNodeSpec(node, spec, valKeysPerNode)
+			nodes = append(nodes, node)
+			index += 1
+		}
+	}
+
+	// the bootnode isn't targetable, so the client under test needs to show up in another node
+	found := false
+	for _, node := range nodes[1:] {
+		if MatchesClient(node.Execution.Type, node.Execution.Version, clientUnderTest) || MatchesClient(node.Consensus.Type, node.Consensus.Version, clientUnderTest) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, stacktrace.NewError("the target client %s does not appear in any non-bootnode node in topology.nodes", clientUnderTest)
+	}
+
+	return nodes, nil
+}
+
+func applyNodeSpec(node *Node, spec NodeSpec, valKeysPerNode int) {
+	node.ConsensusVotes = valKeysPerNode
+	if spec.ValidatorCount != nil {
+		node.ConsensusVotes = *spec.ValidatorCount
+	}
+	// full nodes without validator keys don't run a validator client
+	if node.ConsensusVotes == 0 {
+		node.Consensus.HasValidatorSidecar = false
+		node.Consensus.SidecarCpuRequired = 0
+		node.Consensus.SidecarCpuLimit = 0
+		node.Consensus.SidecarMemoryRequired = 0
+		node.Consensus.SidecarMemoryLimit = 0
+		node.Consensus.ValidatorExtraParams = nil
+	}
+
+	el := node.Execution
+	applyResourceProfile(spec.ElResources, &el.CpuRequired, &el.CpuLimit, &el.MemoryRequired, &el.MemoryLimit)
+	if spec.ElResources.StorageSize != 0 {
+		el.StorageSize = spec.ElResources.StorageSize
+	}
+
+	cl := node.Consensus
+	applyResourceProfile(spec.ClResources, &cl.CpuRequired, &cl.CpuLimit, &cl.MemoryRequired, &cl.MemoryLimit)
+	if spec.ClResources.StorageSize != 0 {
+		cl.StorageSize = spec.ClResources.StorageSize
+	}
+	if cl.HasValidatorSidecar {
+		applyResourceProfile(spec.VcResources, &cl.SidecarCpuRequired, &cl.SidecarCpuLimit, &cl.SidecarMemoryRequired, &cl.SidecarMemoryLimit)
+	}
+
+	el.ExtraParams = append(el.ExtraParams, spec.ElExtraParams...)
+	cl.ExtraParams = append(cl.ExtraParams, spec.ClExtraParams...)
+	if cl.HasValidatorSidecar {
+		cl.ValidatorExtraParams = append(cl.ValidatorExtraParams, spec.VcExtraParams...)
+	}
+
+	for k, v := range spec.ExtraLabels {
+		node.Execution.ExtraLabels[k] = v
+		node.Consensus.ExtraLabels[k] = v
+	}
+}
@@ -0,0 +1,78 @@
+package network
+
+import "testing"
+
+var testExecClients = []ClientVersion{
+	{Name: "geth", Image: "geth:latest"},
+	{Name: "reth", Image: "reth:latest"},
+}
+
+var testConsClients = []ClientVersion{
+	{Name: "lighthouse", Image: "lighthouse:latest,lighthouse:latest", HasSidecar: true},
+	{Name: "prysm", Image: "prysm-beacon:latest,prysm-validator:latest", HasSidecar: true},
+}
+
+func TestComposeCustomTopology(t *testing.T) {
+	topology := Topology{Nodes: []NodeSpec{
+		{Execution: "geth", Consensus: "lighthouse"},
+		{Execution: "reth", Consensus: "prysm", Count: 3},
+		{Execution: "geth", Consensus: "prysm", Count: 2},
+	}}
+	nodes, err := ComposeNetworkTopology(topology, "reth", testExecClients, testConsClients, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 6 {
+		t.Fatalf("expected count to expand to 6 nodes, got %d", len(nodes))
+	}
+	// the first node is the bootnode
+	if nodes[0].Index != 1 || nodes[0].Execution.Type != "geth" || nodes[0].Consensus.Type != "lighthouse" {
+		t.Fatalf("expected the first node spec to be the bootnode, got %s", nodes[0].ToString())
+	}
+	for i, node := range nodes {
+		if node.Index != i+1 {
+			t.Fatalf("expected node %d to have index %d, got %d", i, i+1, node.Index)
+		}
+		if node.ConsensusVotes != 16 {
+			t.Fatalf("expected node %s to hold 16 keys, got %d", node.ToString(), node.ConsensusVotes)
+		}
+	}
+	for _, node := range nodes[1:4] {
+		if node.Execution.Type != "reth" || node.Consensus.Type != "prysm" {
+			t.Fatalf("expected nodes 2-4 to be reth/prysm, got %s", node.ToString())
+		}
+	}
+	if nodes[5].Execution.Type != "geth" || nodes[5].Consensus.Type != "prysm" {
+		t.Fatalf("expected the last node to be geth/prysm, got %s", nodes[5].ToString())
+	}
+}
+
+func TestComposeCustomTopologyErrors(t *testing.T) {
+	type testCase struct {
+		name     string
+		topology Topology
+		target   string
+	}
+	nodes := []NodeSpec{
+		{Execution: "geth", Consensus: "lighthouse"},
+		{Execution: "reth", Consensus: "prysm"},
+	}
+	testCases := []testCase{
+		{name: "unknown el", topology: Topology{Nodes: []NodeSpec{{Execution: "erigon", Consensus: "lighthouse"}}}, target: "geth"},
+		{name: "unknown cl", topology: Topology{Nodes: []NodeSpec{{Execution: "geth", Consensus: "teku"}}}, target: "geth"},
+		{name: "negative count", topology: Topology{Nodes: []NodeSpec{nodes[0], {Execution: "reth", Consensus: "prysm", Count: -1}}}, target: "reth"},
+		// the bootnode isn't targetable, so a target only in the first node spec isn't enough
+		{name: "target only in bootnode", topology: Topology{Nodes: []NodeSpec{nodes[0], nodes[1]}}, target: "lighthouse"},
+		{name: "bootnode_el", topology: Topology{Nodes: nodes, BootnodeEL: "geth"}, target: "reth"},
+		{name: "bootnode_cl", topology: Topology{Nodes: nodes, BootnodeCl: "lighthouse"}, target: "reth"},
+		{name: "targets_as_percent_of_network", topology: Topology{Nodes: nodes, TargetsAsPercentOfNetwork: 0.5}, target: "reth"},
+		{name: "target_node_multiplier", topology: Topology{Nodes: nodes, TargetNodeMultiplier: 2}, target: "reth"},
+	}
+
+	for _, test := range testCases {
+		_, err := ComposeNetworkTopology(test.topology, test.target, testExecClients, testConsClients, 16)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -7,17 +7,26 @@ import (
 const defaultElCpu = 768
 const defaultElMem = 1024
 
-func composeExecTesterNetwork(nodeMultiplier int, execClient string, consClientList []ClientVersion, execClientMap map[string]ClientVersion) ([]*Node, error) {
-	// make sure execClient actually exists
-	clientUnderTest, ok := execClientMap[execClient]
-	if !ok {
-		return nil, stacktrace.NewError("unknown execution client %s", execClient)
-	}
-
+func composeExecTesterNetwork(nodeMultiplier int, execClient string, consClientList []ClientVersion, execClientList []ClientVersion) ([]*Node, error) {
 	// start from 2 because bootnode is index 1
 	index := 2
-	nodes, err := composeNodesForElTesting(nodeMultiplier, index, clientUnderTest, consClientList)
-	return nodes, err
+	var nodes []*Node
+	// every version of the client under test gets tested, unless the target is a specific version
+	for _, clientUnderTest := range execClientList {
+		if !MatchesClient(clientUnderTest.Name, clientUnderTest.Version, execClient) {
+			continue
+		}
+		versionNodes, err := composeNodesForElTesting(nodeMultiplier, index, clientUnderTest, consClientList)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, versionNodes...)
+		index += len(versionNodes)
+	}
+	if len(nodes) == 0 {
+		return nil, stacktrace.NewError("unknown execution client %s", execClient)
+	}
+	return nodes, nil
 }
 
 func composeNodesForElTesting(nodeMultiplier, index int, execClient ClientVersion, consClientList []ClientVersion) ([]*Node, error) {
@@ -35,11 +44,21 @@ func composeNodesForElTesting(nodeMultiplier, index int, execClient ClientVersio
 }
 
 func composeExecutionClient(config ClientVersion) *ExecutionClient {
-	return &ExecutionClient{
+	client := &ExecutionClient{
 		Type:           config.Name,
+		Version:        config.Version,
 		Image:          config.Image,
 		ExtraLabels:    make(map[string]string),
+		ExtraParams:    append([]string{}, config.ExtraParams...),
 		CpuRequired:    defaultElCpu,
+		CpuLimit:       defaultElCpu,
 		MemoryRequired: defaultElMem,
+		MemoryLimit:    defaultElMem,
+		StorageSize:    config.Resources.StorageSize,
+	}
+	applyResourceProfile(config.Resources, &client.CpuRequired, &client.CpuLimit, &client.MemoryRequired, &client.MemoryLimit)
+	if config.Version != "" {
+		client.ExtraLabels[ClientVersionLabel] = config.Version
 	}
+	return client
 }
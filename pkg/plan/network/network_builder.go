@@ -7,13 +7,22 @@ import (
 
 func clientListsToMaps(execClients, consClients []ClientVersion) (execClientMap, consClientMap map[string]ClientVersion, err error) {
 	populateClientMap := func(li []ClientVersion) (map[string]ClientVersion, error) {
+		if err := validateClientVersions(li); err != nil {
+			return nil, err
+		}
 		clients := make(map[string]ClientVersion)
 		for _, client := range li {
-			_, exists := clients[client.Name]
+			_, exists := clients[client.Key()]
 			if exists {
-				return nil, stacktrace.NewError("duplicate configuration for client %s", client.Name)
+				return nil, stacktrace.NewError("duplicate configuration for client %s", client.Key())
+			}
+			if err := validateResourceProfile(client.Resources); err != nil {
+				return nil, stacktrace.Propagate(err, "invalid resources for client %s", client.Name)
+			}
+			if err := validateResourceProfile(client.ValidatorResources); err != nil {
+				return nil, stacktrace.Propagate(err, "invalid validator_resources for client %s", client.Name)
 			}
-			clients[client.Name] = client
+			clients[client.Key()] = client
 		}
 		return clients, nil
 	}
@@ -31,14 +40,14 @@ func clientListsToMaps(execClients, consClients []ClientVersion) (execClientMap,
 	return execClientMap, consClientMap, nil
 }
 
-func ComposeNetworkTopology(topology Topology, clientUnderTest string, execClients, consClients []ClientVersion) ([]*Node, error) {
+func ComposeNetworkTopology(topology Topology, clientUnderTest string, execClients, consClients []ClientVersion, valKeysPerNode int) ([]*Node, error) {
 	if clientUnderTest == "all" {
 		return nil, stacktrace.NewError("target clientUnderTest 'all' not supported yet")
 	}
 
 	isExecutionClient := false
 	for _, execClient := range execClients {
-		if execClient.Name == clientUnderTest {
+		if MatchesClient(execClient.Name, execClient.Version, clientUnderTest) {
 			isExecutionClient = true
 			break
 		}
@@ -49,6 +58,14 @@ func ComposeNetworkTopology(topology Topology, clientUnderTest string, execClien
 		return nil, err
 	}
 
+	if len(topology.Nodes) > 0 {
+		nodes, err := composeCustomTopology(topology, clientUnderTest, execClientMap, consClientMap, valKeysPerNode)
+		if err != nil {
+			return nil, err
+		}
+		return nodes, validateNodeResources(nodes)
+	}
+
 	var nodes []*Node
 	bootnode, err := composeBootnode(topology.BootnodeEL, topology.BootnodeCl, execClientMap, consClientMap)
 	if err != nil {
@@ -65,9 +82,9 @@ func ComposeNetworkTopology(topology Topology, clientUnderTest string, execClien
 	// assume already checked clientUnderTest is a member of consClients or execClients
 	var nodesToTest []*Node
 	if isExecutionClient {
-		nodesToTest, err = composeExecTesterNetwork(nodeMultiplier, clientUnderTest, consClients, execClientMap)
+		nodesToTest, err = composeExecTesterNetwork(nodeMultiplier, clientUnderTest, consClients, execClients)
 	} else {
-		nodesToTest, err = composeConsensusTesterNetwork(nodeMultiplier, clientUnderTest, execClients, consClientMap)
+		nodesToTest, err = composeConsensusTesterNetwork(nodeMultiplier, clientUnderTest, execClients, consClients)
 	}
 	if err != nil {
 		return nil, err
@@ -87,7 +104,11 @@ func ComposeNetworkTopology(topology Topology, clientUnderTest string, execClien
 		return nil, err
 	}
 	nodes = append(nodes, extraNodes...)
-	return nodes, nil
+
+	for _, node := range nodes {
+		node.ConsensusVotes = valKeysPerNode
+	}
+	return nodes, validateNodeResources(nodes)
 }
 
 func composeNodesToSatisfyTargetPercent(percentTarget float32, targetedNodeCount int, startIndex int, clientUnderTest string, execClients, consClients []ClientVersion) ([]*Node, error) {
@@ -112,12 +133,12 @@ func pickExtraNodeClients(startNodeIndex, nodeCount int, clientUnderTest string,
 Exit:
 	for {
 		for execIndex := 0; execIndex < len(execClients); execIndex++ {
-			if execClients[execIndex].Name == clientUnderTest {
+			if MatchesClient(execClients[execIndex].Name, execClients[execIndex].Version, clientUnderTest) {
 				continue
 			}
 
 			for consIndex := 0; consIndex < len(consClients); consIndex++ {
-				if consClients[consIndex].Name == clientUnderTest {
+				if MatchesClient(consClients[consIndex].Name, consClients[consIndex].Version, clientUnderTest) {
 					continue
 				}
 				nodes = append(nodes, buildNode(startNodeIndex, execClients[execIndex], consClients[consIndex]))
@@ -142,7 +163,7 @@ func pickClient(startIndex int, clientUnderTest string, clients []ClientVersion)
 			startIndex = 0
 		}
 
-		if c.Name != clientUnderTest {
+		if !MatchesClient(c.Name, c.Version, clientUnderTest) {
 			return c, startIndex, looped, nil
 		}
 	}
@@ -0,0 +1,71 @@
+package network
+
+import (
+	"github.com/kurtosis-tech/stacktrace"
+)
+
+func validateResourceProfile(profile ResourceProfile) error {
+	values := []int{profile.Cpu, profile.Memory, profile.MinCpu, profile.MaxCpu, profile.MinMemory, profile.MaxMemory, profile.StorageSize}
+	for _, v := range values {
+		if v < 0 {
+			return stacktrace.NewError("resource values must be >= 0")
+		}
+	}
+	if profile.StorageClass != "" {
+		return stacktrace.NewError("storage_class can't be set per client. Set storage-class in the kurtosis cluster config instead")
+	}
+	if profile.MinCpu != 0 && profile.MaxCpu != 0 && profile.MinCpu > profile.MaxCpu {
+		return stacktrace.NewError("min_cpu %d exceeds max_cpu %d", profile.MinCpu, profile.MaxCpu)
+	}
+	if profile.MinMemory != 0 && profile.MaxMemory != 0 && profile.MinMemory > profile.MaxMemory {
+		return stacktrace.NewError("min_mem %d exceeds max_mem %d", profile.MinMemory, profile.MaxMemory)
+	}
+	return nil
+}
+
+// applyResourceProfile layers the profile over the current request and limit. Setting only one side of a range moves
+// the other side along when needed, e.g. a min_cpu above the default limit raises the limit to match.
+func applyResourceProfile(profile ResourceProfile, cpuRequired, cpuLimit, memoryRequired, memoryLimit *int) {
+	applyResourceRange(profile.Cpu, profile.MinCpu, profile.MaxCpu, cpuRequired, cpuLimit)
+	applyResourceRange(profile.Memory, profile.MinMemory, profile.MaxMemory, memoryRequired, memoryLimit)
+}
+
+func applyResourceRange(exact, min, max int, required, limit *int) {
+	if exact != 0 {
+		*required = exact
+		*limit = exact
+	}
+	if min != 0 {
+		*required = min
+	}
+	if max != 0 {
+		*limit = max
+	}
+
+	if *required > *limit {
+		if max == 0 {
+			*limit = *required
+		} else if min == 0 {
+			*required = *limit
+		}
+	}
+}
+
+// validateNodeResources makes sure layering client and node resource profiles on top of the defaults didn't produce a
+// request larger than its limit.
+func validateNodeResources(nodes []*Node) error {
+	for _, node := range nodes {
+		el := node.Execution
+		cl := node.Consensus
+		if el.CpuRequired > el.CpuLimit || el.MemoryRequired > el.MemoryLimit {
+			return stacktrace.NewError("node %s has an execution client resource request larger than its limit", node.ToString())
+		}
+		if cl.CpuRequired > cl.CpuLimit || cl.MemoryRequired > cl.MemoryLimit {
+			return stacktrace.NewError("node %s has a consensus client resource request larger than its limit", node.ToString())
+		}
+		if cl.SidecarCpuRequired > cl.SidecarCpuLimit || cl.SidecarMemoryRequired > cl.SidecarMemoryLimit {
+			return stacktrace.NewError("node %s has a validator resource request larger than its limit", node.ToString())
+		}
+	}
+	return nil
+}
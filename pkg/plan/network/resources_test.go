@@ -0,0 +1,85 @@
+package network
+
+import "testing"
+
+func TestApplyResourceProfile(t *testing.T) {
+	type testCase struct {
+		name                                         string
+		profile                                      ResourceProfile
+		cpuRequired, cpuLimit, memRequired, memLimit int
+	}
+	// defaults of 1000m cpu and 1536MB memory for request and limit
+	testCases := []testCase{
+		{name: "empty profile keeps defaults", profile: ResourceProfile{}, cpuRequired: 1000, cpuLimit: 1000, memRequired: 1536, memLimit: 1536},
+		{name: "exact values set both", profile: ResourceProfile{Cpu: 2000, Memory: 4096}, cpuRequired: 2000, cpuLimit: 2000, memRequired: 4096, memLimit: 4096},
+		{name: "min below the limit", profile: ResourceProfile{MinCpu: 500, MinMemory: 1024}, cpuRequired: 500, cpuLimit: 1000, memRequired: 1024, memLimit: 1536},
+		{name: "min above the limit raises it", profile: ResourceProfile{MinCpu: 3000, MinMemory: 8192}, cpuRequired: 3000, cpuLimit: 3000, memRequired: 8192, memLimit: 8192},
+		{name: "max below the request lowers it", profile: ResourceProfile{MaxCpu: 250, MaxMemory: 512}, cpuRequired: 250, cpuLimit: 250, memRequired: 512, memLimit: 512},
+		{name: "min and max", profile: ResourceProfile{MinCpu: 1500, MaxCpu: 4000, MinMemory: 2048, MaxMemory: 4096}, cpuRequired: 1500, cpuLimit: 4000, memRequired: 2048, memLimit: 4096},
+		{name: "min and max override exact", profile: ResourceProfile{Cpu: 2000, MaxCpu: 3000, Memory: 2048, MinMemory: 1024}, cpuRequired: 2000, cpuLimit: 3000, memRequired: 1024, memLimit: 2048},
+	}
+
+	for _, test := range testCases {
+		cpuRequired, cpuLimit, memRequired, memLimit := defaultClCpu, defaultClCpu, defaultClMem, defaultClMem
+		applyResourceProfile(test.profile, &cpuRequired, &cpuLimit, &memRequired, &memLimit)
+		if cpuRequired != test.cpuRequired || cpuLimit != test.cpuLimit || memRequired != test.memRequired || memLimit != test.memLimit {
+			t.Errorf("%s: expected cpu %d/%d mem %d/%d, got cpu %d/%d mem %d/%d", test.name,
+				test.cpuRequired, test.cpuLimit, test.memRequired, test.memLimit, cpuRequired, cpuLimit, memRequired, memLimit)
+		}
+	}
+}
+
+func TestResourceProfilePrecedence(t *testing.T) {
+	execClients := []ClientVersion{
+		{Name: "geth", Image: "geth:latest", Resources: ResourceProfile{Cpu: 2000}},
+		{Name: "reth", Image: "reth:latest"},
+	}
+	consClients := []ClientVersion{
+		{Name: "lighthouse", Image: "lighthouse:latest", HasSidecar: true, ValidatorResources: ResourceProfile{MinMemory: 1024}},
+	}
+	topology := Topology{Nodes: []NodeSpec{
+		{Execution: "reth", Consensus: "lighthouse"},
+		{Execution: "geth", Consensus: "lighthouse"},
+		{Execution: "geth", Consensus: "lighthouse", ElResources: ResourceProfile{MinCpu: 3000}, VcResources: ResourceProfile{Memory: 256}},
+	}}
+	nodes, err := ComposeNetworkTopology(topology, "geth", execClients, consClients, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no profile: defaults
+	if el := nodes[0].Execution; el.CpuRequired != defaultElCpu || el.CpuLimit != defaultElCpu {
+		t.Fatalf("expected default el cpu, got %d/%d", el.CpuRequired, el.CpuLimit)
+	}
+	// client profile over defaults
+	if el := nodes[1].Execution; el.CpuRequired != 2000 || el.CpuLimit != 2000 {
+		t.Fatalf("expected the client profile to set el cpu to 2000, got %d/%d", el.CpuRequired, el.CpuLimit)
+	}
+	if cl := nodes[1].Consensus; cl.SidecarMemoryRequired != 1024 || cl.SidecarMemoryLimit != 1024 {
+		t.Fatalf("expected the client validator profile to raise vc memory to 1024, got %d/%d", cl.SidecarMemoryRequired, cl.SidecarMemoryLimit)
+	}
+	// node profile over client profile
+	if el := nodes[2].Execution; el.CpuRequired != 3000 || el.CpuLimit != 3000 {
+		t.Fatalf("expected the node profile to raise el cpu to 3000, got %d/%d", el.CpuRequired, el.CpuLimit)
+	}
+	if cl := nodes[2].Consensus; cl.SidecarMemoryRequired != 256 || cl.SidecarMemoryLimit != 256 {
+		t.Fatalf("expected the node profile to set vc memory to 256, got %d/%d", cl.SidecarMemoryRequired, cl.SidecarMemoryLimit)
+	}
+}
+
+func TestValidateResourceProfile(t *testing.T) {
+	invalid := []ResourceProfile{
+		{Cpu: -1},
+		{MinCpu: 2000, MaxCpu: 1000},
+		{MinMemory: 2048, MaxMemory: 1024},
+		{StorageClass: "fast-ssd"},
+	}
+	for _, profile := range invalid {
+		if validateResourceProfile(profile) == nil {
+			t.Errorf("expected %+v to be invalid", profile)
+		}
+	}
+	if err := validateResourceProfile(ResourceProfile{MinCpu: 4000}); err != nil {
+		t.Errorf("expected a min_cpu above the default limit to be valid, got %s", err)
+	}
+}
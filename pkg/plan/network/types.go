@@ -19,36 +19,87 @@ type GenesisConfig struct {
 }
 
 type Topology struct {
-	BootnodeEL                string  `yaml:"bootnode_el"`
-	BootnodeCl                string  `yaml:"bootnode_cl"`
-	TargetsAsPercentOfNetwork float32 `yaml:"targets_as_percent_of_network"`
-	TargetNodeMultiplier      uint    `yaml:"target_node_multiplier"`
+	BootnodeEL                string     `yaml:"bootnode_el"`
+	BootnodeCl                string     `yaml:"bootnode_cl"`
+	TargetsAsPercentOfNetwork float32    `yaml:"targets_as_percent_of_network"`
+	TargetNodeMultiplier      uint       `yaml:"target_node_multiplier"`
+	Nodes                     []NodeSpec `yaml:"nodes,omitempty"`
+}
+
+// NodeSpec explicitly defines one or more identical nodes in a custom topology. The first node defined is used as the
+// bootnode.
+type NodeSpec struct {
+	Execution      string            `yaml:"el"`
+	Consensus      string            `yaml:"cl"`
+	Count          int               `yaml:"count,omitempty"`
+	ValidatorCount *int              `yaml:"validator_count,omitempty"`
+	ElResources    ResourceProfile   `yaml:"el_resources,omitempty"`
+	ClResources    ResourceProfile   `yaml:"cl_resources,omitempty"`
+	VcResources    ResourceProfile   `yaml:"vc_resources,omitempty"`
+	ExtraLabels    map[string]string `yaml:"extra_labels,omitempty"`
+	ElExtraParams  []string          `yaml:"el_extra_params,omitempty"`
+	ClExtraParams  []string          `yaml:"cl_extra_params,omitempty"`
+	VcExtraParams  []string          `yaml:"vc_extra_params,omitempty"`
+}
+
+// ResourceProfile overrides the default cpu (millicores), memory (MB) and storage (MB) allocated to a client. cpu and
+// mem set the request and the limit to the same value, min_* sets the request and max_* sets the limit. Zero values
+// keep the defaults.
+type ResourceProfile struct {
+	Cpu         int `yaml:"cpu,omitempty"`
+	Memory      int `yaml:"mem,omitempty"`
+	MinCpu      int `yaml:"min_cpu,omitempty"`
+	MaxCpu      int `yaml:"max_cpu,omitempty"`
+	MinMemory   int `yaml:"min_mem,omitempty"`
+	MaxMemory   int `yaml:"max_mem,omitempty"`
+	StorageSize int `yaml:"storage_size,omitempty"`
+	// the ethereum-package has no per-participant storage class, it's set for the whole cluster in the kurtosis
+	// config. Only decoded so a profile that sets it is rejected instead of silently ignored.
+	StorageClass string `yaml:"storage_class,omitempty"`
 }
 
 type ClientVersion struct {
-	Name       string `yaml:"name"`
-	Image      string `yaml:"image"`
-	HasSidecar bool   `yaml:"has_sidecar,omitempty"`
+	Name               string          `yaml:"name"`
+	Version            string          `yaml:"version,omitempty"` // distinguishes several versions of the same client
+	Image              string          `yaml:"image"`
+	HasSidecar         bool            `yaml:"has_sidecar,omitempty"`
+	Resources          ResourceProfile `yaml:"resources,omitempty"`
+	ValidatorResources ResourceProfile `yaml:"validator_resources,omitempty"`
+	ExtraParams        []string        `yaml:"extra_params,omitempty"`
+	ValidatorParams    []string        `yaml:"validator_extra_params,omitempty"`
 }
 
 type ExecutionClient struct {
 	Type           string
+	Version        string
 	Image          string
 	ExtraLabels    map[string]string
+	ExtraParams    []string
 	CpuRequired    int
+	CpuLimit       int
 	MemoryRequired int
+	MemoryLimit    int
+	StorageSize    int
 }
 
 type ConsensusClient struct {
 	Type                  string
+	Version               string
 	Image                 string
 	HasValidatorSidecar   bool
 	ValidatorImage        string
 	ExtraLabels           map[string]string
+	ExtraParams           []string
+	ValidatorExtraParams  []string
 	CpuRequired           int
+	CpuLimit              int
 	MemoryRequired        int
+	MemoryLimit           int
+	StorageSize           int
 	SidecarCpuRequired    int
+	SidecarCpuLimit       int
 	SidecarMemoryRequired int
+	SidecarMemoryLimit    int
 }
 
 type Node struct {
@@ -59,5 +110,21 @@ type Node struct {
 }
 
 func (n *Node) ToString() string {
-	return fmt.Sprintf("#%d %s/%s", n.Index, n.Execution.Type, n.Consensus.Type)
+	return fmt.Sprintf("#%d %s/%s", n.Index, clientDescription(n.Execution.Type, n.Execution.Version), clientDescription(n.Consensus.Type, n.Consensus.Version))
+}
+
+func clientDescription(clientType, version string) string {
+	if version == "" {
+		return clientType
+	}
+	return clientType + clientVersionSeparator + version
+}
+
+// CountConsensusVotes returns the number of validator keys held across the nodes.
+func CountConsensusVotes(nodes []*Node) int {
+	votes := 0
+	for _, n := range nodes {
+		votes += n.ConsensusVotes
+	}
+	return votes
 }
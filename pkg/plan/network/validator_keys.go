@@ -0,0 +1,50 @@
+package network
+
+import (
+	"github.com/kurtosis-tech/stacktrace"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultKurtosisValKeysPerNode is the ethereum-package's default for network_params.num_validator_keys_per_node.
+const DefaultKurtosisValKeysPerNode = 64
+
+// validatorKeysConfig is the part of a Kurtosis network config that decides how many validator keys each node holds.
+type validatorKeysConfig struct {
+	Participants []struct {
+		Count          int  `yaml:"count"`
+		ValidatorCount *int `yaml:"validator_count"`
+	} `yaml:"participants"`
+	NetParams struct {
+		NumValKeysPerNode int `yaml:"num_validator_keys_per_node"`
+	} `yaml:"network_params"`
+}
+
+// ValidatorKeysByNodeIndex maps each node index in a Kurtosis network config to the number of validator keys it holds.
+// Participants with a count > 1 are deployed as separate nodes.
+func ValidatorKeysByNodeIndex(conf []byte) (map[int]int, error) {
+	parsedConf := validatorKeysConfig{}
+	err := yaml.Unmarshal(conf, &parsedConf)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to parse eth network types")
+	}
+	defaultKeys := parsedConf.NetParams.NumValKeysPerNode
+	if defaultKeys == 0 {
+		defaultKeys = DefaultKurtosisValKeysPerNode
+	}
+
+	keys := make(map[int]int)
+	for _, participant := range parsedConf.Participants {
+		count := participant.Count
+		if count == 0 {
+			count = 1
+		}
+		validatorCount := defaultKeys
+		if participant.ValidatorCount != nil {
+			validatorCount = *participant.ValidatorCount
+		}
+		for i := 0; i < count; i++ {
+			keys[len(keys)+1] = validatorCount
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,47 @@
+package network
+
+import "testing"
+
+func TestValidatorKeysByNodeIndex(t *testing.T) {
+	conf := []byte(`
+participants:
+  - el_type: geth
+    cl_type: lighthouse
+    count: 2
+  - el_type: reth
+    cl_type: teku
+    validator_count: 0
+  - el_type: nethermind
+    cl_type: prysm
+    validator_count: 128
+network_params:
+  num_validator_keys_per_node: 32
+`)
+	keys, err := ValidatorKeysByNodeIndex(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]int{1: 32, 2: 32, 3: 0, 4: 128}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d nodes, got %v", len(expected), keys)
+	}
+	for index, count := range expected {
+		if keys[index] != count {
+			t.Errorf("expected node %d to hold %d keys, got %d", index, count, keys[index])
+		}
+	}
+
+	// the ethereum-package default applies when num_validator_keys_per_node is missing
+	keys, err = ValidatorKeysByNodeIndex([]byte("participants:\n  - el_type: geth\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] != DefaultKurtosisValKeysPerNode {
+		t.Fatalf("expected the default of %d keys, got %d", DefaultKurtosisValKeysPerNode, keys[1])
+	}
+
+	_, err = ValidatorKeysByNodeIndex([]byte("participants: {"))
+	if err == nil {
+		t.Fatal("expected an invalid config to fail")
+	}
+}
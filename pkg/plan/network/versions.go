@@ -0,0 +1,58 @@
+package network
+
+import (
+	"github.com/kurtosis-tech/stacktrace"
+	"regexp"
+	"strings"
+)
+
+// ClientVersionLabel is applied to every pod of a client that was configured with a version, so several versions of
+// the same client can be told apart.
+const ClientVersionLabel = "attacknet/client-version"
+
+// clients with a version are referenced as <name>@<version>
+const clientVersionSeparator = "@"
+
+var labelValuePattern = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+
+// Key returns the reference used for the client in topologies and target_client.
+func (c ClientVersion) Key() string {
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + clientVersionSeparator + c.Version
+}
+
+// MatchesClient reports whether a client of the given type and version is matched by a client reference. References
+// without a version match every version of the client.
+func MatchesClient(clientType, version, ref string) bool {
+	name, refVersion, hasVersion := strings.Cut(ref, clientVersionSeparator)
+	if name != clientType {
+		return false
+	}
+	return !hasVersion || refVersion == version
+}
+
+func validateClientVersions(clients []ClientVersion) error {
+	versioned := make(map[string]bool)
+	unversioned := make(map[string]bool)
+	for _, client := range clients {
+		if strings.Contains(client.Name, clientVersionSeparator) {
+			return stacktrace.NewError("client name %s cannot contain '%s'. Use the version field instead", client.Name, clientVersionSeparator)
+		}
+		if client.Version == "" {
+			unversioned[client.Name] = true
+			continue
+		}
+		if len(client.Version) > 63 || !labelValuePattern.MatchString(client.Version) {
+			return stacktrace.NewError("version %s of client %s must be a valid kubernetes label value", client.Version, client.Name)
+		}
+		versioned[client.Name] = true
+	}
+	for name := range versioned {
+		if unversioned[name] {
+			return stacktrace.NewError("client %s is configured both with and without a version. Set a version on every configuration of %s", name, name)
+		}
+	}
+	return nil
+}
@@ -4,34 +4,101 @@ import (
 	"attacknet/cmd/pkg/plan/network"
 	"attacknet/cmd/pkg/plan/suite"
 	types "attacknet/cmd/pkg/types"
+	"fmt"
+	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
+	"os"
 )
 
-func BuildPlan(planName string, config *PlannerConfig) error {
-
-	netRefPath, netConfigPath, suiteConfigPath, err := preparePaths(planName)
-	if err != nil {
-		return err
-	}
-
+func BuildPlan(planName string, config *PlannerConfig, opts OutputOptions) error {
 	nodes, err := network.ComposeNetworkTopology(
 		config.Topology,
 		config.FaultConfig.TargetClient,
 		config.ExecutionClients,
 		config.ConsensusClients,
+		config.GenesisParams.NumValKeysPerNode,
 	)
 	if err != nil {
 		return err
 	}
 
-	isExecTarget := config.IsTargetExecutionClient()
+	networkConfig, err := SerializeNetworkTopology(nodes, &config.GenesisParams, &config.NetworkFeatures)
+	if err != nil {
+		return err
+	}
+
+	return buildSuite(planName, config, opts, &config.GenesisParams, nodes, config.IsTargetExecutionClient(), config.NetworkFeatures.MevType, networkConfig)
+}
+
+// BuildPlanFromNetwork composes a test suite against the participants of an existing network config. The network
+// config is copied into the plan as-is.
+func BuildPlanFromNetwork(planName string, config *PlannerConfig, networkConfigPath string, opts OutputOptions) error {
+	networkConfig, err := os.ReadFile(networkConfigPath)
+	if err != nil {
+		return stacktrace.Propagate(err, "could not read network config on path %s", networkConfigPath)
+	}
+
+	nodes, err := DeserializeNetworkTopology(networkConfig)
+	if err != nil {
+		return err
+	}
+	parsedNetworkConfig := EthKurtosisConfig{}
+	err = yaml.Unmarshal(networkConfig, &parsedNetworkConfig)
+	if err != nil {
+		return stacktrace.Propagate(err, "unable to parse network config on path %s", networkConfigPath)
+	}
+	if len(nodes) < 2 {
+		return stacktrace.NewError("network config %s must contain at least 2 nodes, found %d", networkConfigPath, len(nodes))
+	}
+
+	isExecTarget := false
+	isConsensusTarget := false
+	for _, node := range nodes[1:] {
+		if network.MatchesClient(node.Execution.Type, node.Execution.Version, config.FaultConfig.TargetClient) {
+			isExecTarget = true
+		}
+		if network.MatchesClient(node.Consensus.Type, node.Consensus.Version, config.FaultConfig.TargetClient) {
+			isConsensusTarget = true
+		}
+	}
+	if !isExecTarget && !isConsensusTarget {
+		return stacktrace.NewError("target_client %s is not used by any non-bootnode participant in %s", config.FaultConfig.TargetClient, networkConfigPath)
+	}
+
+	return buildSuite(planName, config, opts, &parsedNetworkConfig.NetParams, nodes, isExecTarget, parsedNetworkConfig.MevType, networkConfig)
+}
+
+func buildSuite(planName string, config *PlannerConfig, opts OutputOptions, genesis *network.GenesisConfig, nodes []*network.Node, isExecTarget bool, mevType string, networkConfig []byte) error {
 	// exclude the bootnode from test targeting
 	potentialNodesUnderTest := nodes[1:]
-	tests, err := suite.ComposeTestSuite(config.FaultConfig, isExecTarget, potentialNodesUnderTest)
+	tests, err := suite.ComposeTestSuite(config.FaultConfig, isExecTarget, potentialNodesUnderTest, network.CountConsensusVotes(nodes), mevType)
 	if err != nil {
 		return err
 	}
 
+	estimate, err := EstimatePlan(config, genesis, nodes, tests)
+	if err != nil {
+		return err
+	}
+	log.Infof("ESTIMATE: Running this test suite will take between %s and %s.", estimate.TotalMinimum, estimate.TotalWorstCase)
+	if opts.EstimateOnly {
+		bs, err := yaml.Marshal(estimate)
+		if err != nil {
+			return stacktrace.Propagate(err, "unable to marshal plan estimate")
+		}
+		fmt.Print(string(bs))
+		return nil
+	}
+
+	netRefPath, netConfigPath, suiteConfigPaths, stalePaths, err := preparePaths(planName, opts)
+	if err != nil {
+		return err
+	}
+	if len(suiteConfigPaths) > len(tests) {
+		return stacktrace.NewError("cannot split %d tests into %d suites", len(tests), len(suiteConfigPaths))
+	}
+
 	var attacknetConfig types.AttacknetConfig
 	if config.KubernetesNamespace == "" {
 		attacknetConfig = types.AttacknetConfig{
@@ -52,25 +119,39 @@ func BuildPlan(planName string, config *PlannerConfig) error {
 		}
 	}
 
-	c := types.Config{
-		AttacknetConfig: attacknetConfig,
-		HarnessConfig: types.HarnessConfig{
-			NetworkPackage:    config.KurtosisPackage,
-			NetworkConfigPath: netRefPath,
-			NetworkType:       "ethereum",
-		},
-		TestConfig: types.SuiteTestConfigs{Tests: tests},
-	}
+	var suiteConfigs [][]byte
+	for _, suiteTests := range splitTests(tests, len(suiteConfigPaths)) {
+		c := types.Config{
+			AttacknetConfig: attacknetConfig,
+			HarnessConfig: types.HarnessConfig{
+				NetworkPackage:    config.KurtosisPackage,
+				NetworkConfigPath: netRefPath,
+				NetworkType:       "ethereum",
+			},
+			TestConfig: types.SuiteTestConfigs{Tests: suiteTests},
+		}
 
-	suiteConfig, err := yaml.Marshal(c)
-	if err != nil {
-		return err
+		suiteConfig, err := yaml.Marshal(c)
+		if err != nil {
+			return err
+		}
+		suiteConfigs = append(suiteConfigs, suiteConfig)
 	}
 
-	networkConfig, err := SerializeNetworkTopology(nodes, &config.GenesisParams)
-	if err != nil {
-		return err
-	}
+	return writePlans(netConfigPath, suiteConfigPaths, stalePaths, networkConfig, suiteConfigs)
+}
 
-	return writePlans(netConfigPath, suiteConfigPath, networkConfig, suiteConfig)
+// splitTests partitions tests into n contiguous groups whose sizes differ by at most one.
+func splitTests(tests []types.SuiteTest, n int) [][]types.SuiteTest {
+	groups := make([][]types.SuiteTest, n)
+	start := 0
+	for i := 0; i < n; i++ {
+		size := len(tests) / n
+		if i < len(tests)%n {
+			size++
+		}
+		groups[i] = tests[start : start+size]
+		start += size
+	}
+	return groups
 }
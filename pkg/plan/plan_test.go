@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"attacknet/cmd/pkg/plan/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a curated network config without vc_ resources, like the ones in network-configs/
+const curatedNetworkConfig = `participants:
+  - el_type: geth
+    el_image: ethereum/client-go:v1.13.14
+    cl_type: lighthouse
+    cl_image: sigp/lighthouse:v5.1.1
+  - el_type: geth
+    el_image: ethereum/client-go:v1.13.14
+    cl_type: lighthouse
+    cl_image: sigp/lighthouse:v5.1.1
+  - el_type: geth
+    el_image: ethereum/client-go:v1.13.14
+    cl_type: teku
+    cl_image: consensys/teku:24.3.0
+network_params:
+  num_validator_keys_per_node: 32
+`
+
+func newRestartPlannerConfig(targetClient string) *PlannerConfig {
+	return &PlannerConfig{
+		FaultConfig: suite.PlannerFaultConfiguration{
+			FaultType:            suite.FaultContainerRestart,
+			TargetClient:         targetClient,
+			TargetingDimensions:  []suite.TargetingSpec{suite.TargetMatchingNode},
+			AttackSizeDimensions: []suite.AttackSize{suite.AttackAll},
+			Dimensions:           []suite.FaultDimension{&suite.ContainerRestartDimension{GracePeriod: time.Minute}},
+		},
+	}
+}
+
+func TestBuildPlanFromNetworkInfersValidatorSidecar(t *testing.T) {
+	dir := t.TempDir()
+	networkConfigPath := filepath.Join(dir, "curated.yaml")
+	err := os.WriteFile(networkConfigPath, []byte(curatedNetworkConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = BuildPlanFromNetwork("curated", newRestartPlannerConfig("geth"), networkConfigPath, OutputOptions{Dir: dir, Split: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, "test-suites", "plan", "curated.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	suiteConfig := string(bs)
+	if !strings.Contains(suiteConfig, "val-2-lighthouse-geth") {
+		t.Fatalf("expected the lighthouse validator client to be targeted, got:\n%s", suiteConfig)
+	}
+	if strings.Contains(suiteConfig, "val-3-teku-geth") {
+		t.Fatalf("expected teku to run without a validator client, got:\n%s", suiteConfig)
+	}
+}
+
+func TestHasValidatorSidecar(t *testing.T) {
+	separate := true
+	inProcess := false
+	type testCase struct {
+		name        string
+		participant Participant
+		expected    bool
+	}
+	testCases := []testCase{
+		{name: "lighthouse default", participant: Participant{ClClientType: "lighthouse"}, expected: true},
+		{name: "teku default", participant: Participant{ClClientType: "teku"}, expected: false},
+		{name: "teku with vc resources", participant: Participant{ClClientType: "teku", ValMaxCpu: 1000}, expected: true},
+		{name: "teku with vc labels", participant: Participant{ClClientType: "teku", VcExtraLabels: map[string]string{"a": "b"}}, expected: true},
+		{name: "use_separate_vc true", participant: Participant{ClClientType: "nimbus", UseSeparateVc: &separate}, expected: true},
+		{name: "use_separate_vc false", participant: Participant{ClClientType: "lighthouse", ValMaxCpu: 1000, UseSeparateVc: &inProcess}, expected: false},
+	}
+	for _, test := range testCases {
+		if hasValidatorSidecar(&test.participant) != test.expected {
+			t.Errorf("%s: expected sidecar %v", test.name, test.expected)
+		}
+	}
+}
+
+func TestTargetClientAllRejected(t *testing.T) {
+	for _, target := range []string{"all", ""} {
+		err := validatePlannerFaultConfiguration(*newRestartPlannerConfig(target))
+		if err == nil {
+			t.Errorf("expected target_client '%s' to be rejected", target)
+		}
+	}
+	err := validatePlannerFaultConfiguration(*newRestartPlannerConfig("geth"))
+	if err != nil {
+		t.Fatalf("expected target_client geth to be valid, got %s", err)
+	}
+}
@@ -7,19 +7,27 @@ import (
 	"strings"
 )
 
-func SerializeNetworkTopology(nodes []*network.Node, config *network.GenesisConfig) ([]byte, error) {
+func SerializeNetworkTopology(nodes []*network.Node, config *network.GenesisConfig, features *NetworkFeatures) ([]byte, error) {
 	serializableNodes := serializeNodes(nodes)
 
+	additionalServices := defaultAdditionalServices
+	if features.AdditionalServices != nil {
+		additionalServices = *features.AdditionalServices
+	}
+	disablePeerScoring := true
+	if features.DisablePeerScoring != nil {
+		disablePeerScoring = *features.DisablePeerScoring
+	}
+
 	netConfig := &EthKurtosisConfig{
-		Participants: serializableNodes,
-		NetParams:    *config,
-		AdditionalServices: []string{
-			"prometheus_grafana",
-			"dora",
-		},
-		ParallelKeystoreGen: false,
-		Persistent:          false,
-		DisablePeerScoring:  true,
+		Participants:        serializableNodes,
+		NetParams:           *config,
+		AdditionalServices:  additionalServices,
+		ParallelKeystoreGen: features.ParallelKeystoreGen,
+		Persistent:          features.Persistent,
+		DisablePeerScoring:  disablePeerScoring,
+		MevType:             features.MevType,
+		MevParams:           features.MevParams,
 	}
 
 	bs, err := yaml.Marshal(netConfig)
@@ -36,52 +44,114 @@ func DeserializeNetworkTopology(conf []byte) ([]*network.Node, error) {
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "unable to parse eth network types")
 	}
+	if parsedConf.NetParams.NumValKeysPerNode == 0 {
+		parsedConf.NetParams.NumValKeysPerNode = network.DefaultKurtosisValKeysPerNode
+	}
 
 	var nodes []*network.Node
 
-	for i, participant := range parsedConf.Participants {
-		hasSidecar := false
-		consensusImage := participant.ClClientImage
-		validatorImage := ""
-		if participant.ValMaxCpu != 0 {
-			hasSidecar = true
-			// todo: remove this
-			if strings.Contains(consensusImage, ",") {
-				images := strings.Split(consensusImage, ",")
-				consensusImage = images[0]
-				validatorImage = images[1]
-			}
+	for _, participant := range parsedConf.Participants {
+		// participants with a count > 1 are deployed as separate nodes
+		count := participant.Count
+		if count == 0 {
+			count = 1
 		}
+		for i := 0; i < count; i++ {
+			node := deserializeParticipant(len(nodes)+1, participant, parsedConf.NetParams.NumValKeysPerNode)
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
 
-		votesPerNode := parsedConf.NetParams.NumValKeysPerNode
-
-		node := &network.Node{
-			Index:          i + 1,
-			ConsensusVotes: votesPerNode,
-			Consensus: &network.ConsensusClient{
-				Type:                  participant.ClClientType,
-				Image:                 consensusImage,
-				ValidatorImage:        validatorImage,
-				HasValidatorSidecar:   hasSidecar,
-				ExtraLabels:           map[string]string{},
-				CpuRequired:           participant.ClMinCpu,
-				MemoryRequired:        participant.ClMinMemory,
-				SidecarCpuRequired:    participant.ValMinCpu,
-				SidecarMemoryRequired: participant.ValMinMemory,
-			},
-			Execution: &network.ExecutionClient{
-				Type:           participant.ElClientType,
-				Image:          participant.ElClientImage,
-				ExtraLabels:    map[string]string{},
-				CpuRequired:    participant.ElMinCpu,
-				MemoryRequired: participant.ElMinMemory,
-			},
+// separateVcClients are the consensus clients the ethereum-package runs a separate validator client for by default.
+var separateVcClients = map[string]bool{
+	"lighthouse": true,
+	"lodestar":   true,
+	"prysm":      true,
+}
+
+// hasValidatorSidecar decides whether a participant runs a separate validator client. Curated network configs rarely
+// set vc resources, so the client type decides unless use_separate_vc or a vc_ field says otherwise.
+func hasValidatorSidecar(participant *Participant) bool {
+	if participant.UseSeparateVc != nil {
+		return *participant.UseSeparateVc
+	}
+	if participant.ValMinCpu != 0 || participant.ValMaxCpu != 0 || participant.ValMinMemory != 0 || participant.ValMaxMemory != 0 {
+		return true
+	}
+	if len(participant.VcExtraLabels) > 0 || len(participant.VcExtraParams) > 0 {
+		return true
+	}
+	return separateVcClients[participant.ClClientType]
+}
+
+func deserializeParticipant(index int, participant *Participant, defaultValidatorCount int) *network.Node {
+	hasSidecar := hasValidatorSidecar(participant)
+	consensusImage := participant.ClClientImage
+	validatorImage := ""
+	if hasSidecar {
+		// todo: remove this
+		if strings.Contains(consensusImage, ",") {
+			images := strings.Split(consensusImage, ",")
+			consensusImage = images[0]
+			validatorImage = images[1]
 		}
+	}
 
-		nodes = append(nodes, node)
+	votesPerNode := defaultValidatorCount
+	if participant.ValidatorCount != nil {
+		votesPerNode = *participant.ValidatorCount
+	}
+	// nodes without validator keys don't run a validator client
+	if votesPerNode == 0 {
+		hasSidecar = false
+	}
 
+	elLabels := make(map[string]string)
+	for k, v := range participant.ElExtraLabels {
+		elLabels[k] = v
+	}
+	clLabels := make(map[string]string)
+	for k, v := range participant.ClExtraLabels {
+		clLabels[k] = v
+	}
+
+	return &network.Node{
+		Index:          index,
+		ConsensusVotes: votesPerNode,
+		Consensus: &network.ConsensusClient{
+			Type:                  participant.ClClientType,
+			Version:               clLabels[network.ClientVersionLabel],
+			Image:                 consensusImage,
+			ValidatorImage:        validatorImage,
+			HasValidatorSidecar:   hasSidecar,
+			ExtraLabels:           clLabels,
+			ExtraParams:           participant.ClExtraParams,
+			ValidatorExtraParams:  participant.VcExtraParams,
+			CpuRequired:           participant.ClMinCpu,
+			CpuLimit:              participant.ClMaxCpu,
+			MemoryRequired:        participant.ClMinMemory,
+			MemoryLimit:           participant.ClMaxMemory,
+			StorageSize:           participant.ClVolumeSize,
+			SidecarCpuRequired:    participant.ValMinCpu,
+			SidecarCpuLimit:       participant.ValMaxCpu,
+			SidecarMemoryRequired: participant.ValMinMemory,
+			SidecarMemoryLimit:    participant.ValMaxMemory,
+		},
+		Execution: &network.ExecutionClient{
+			Type:           participant.ElClientType,
+			Version:        elLabels[network.ClientVersionLabel],
+			Image:          participant.ElClientImage,
+			ExtraLabels:    elLabels,
+			ExtraParams:    participant.ElExtraParams,
+			CpuRequired:    participant.ElMinCpu,
+			CpuLimit:       participant.ElMaxCpu,
+			MemoryRequired: participant.ElMinMemory,
+			MemoryLimit:    participant.ElMaxMemory,
+			StorageSize:    participant.ElVolumeSize,
+		},
 	}
-	return nodes, nil
 }
 
 func serializeNodes(nodes []*network.Node) []*Participant {
@@ -94,6 +164,12 @@ func serializeNodes(nodes []*network.Node) []*Participant {
 		//	consensusImage = consensusImage + fmt.Sprintf(",%s", node.Consensus.ValidatorImage)
 		//}
 
+		validatorCount := node.ConsensusVotes
+		var validatorLabels map[string]string
+		if node.Consensus.HasValidatorSidecar {
+			validatorLabels = node.Consensus.ExtraLabels
+		}
+
 		p := &Participant{
 			ElClientType:  node.Execution.Type,
 			ElClientImage: node.Execution.Image,
@@ -101,20 +177,33 @@ func serializeNodes(nodes []*network.Node) []*Participant {
 			ClClientType:  node.Consensus.Type,
 			ClClientImage: consensusImage,
 
+			ElExtraLabels: node.Execution.ExtraLabels,
+			ClExtraLabels: node.Consensus.ExtraLabels,
+			VcExtraLabels: validatorLabels,
+
+			ValidatorCount: &validatorCount,
+
+			ElExtraParams: node.Execution.ExtraParams,
+			ClExtraParams: node.Consensus.ExtraParams,
+			VcExtraParams: node.Consensus.ValidatorExtraParams,
+
 			ElMinCpu:    node.Execution.CpuRequired,
-			ElMaxCpu:    node.Execution.CpuRequired,
+			ElMaxCpu:    limitOrRequired(node.Execution.CpuLimit, node.Execution.CpuRequired),
 			ElMinMemory: node.Execution.MemoryRequired,
-			ElMaxMemory: node.Execution.MemoryRequired,
+			ElMaxMemory: limitOrRequired(node.Execution.MemoryLimit, node.Execution.MemoryRequired),
 
 			ClMinCpu:    node.Consensus.CpuRequired,
-			ClMaxCpu:    node.Consensus.CpuRequired,
+			ClMaxCpu:    limitOrRequired(node.Consensus.CpuLimit, node.Consensus.CpuRequired),
 			ClMinMemory: node.Consensus.MemoryRequired,
-			ClMaxMemory: node.Consensus.MemoryRequired,
+			ClMaxMemory: limitOrRequired(node.Consensus.MemoryLimit, node.Consensus.MemoryRequired),
 
 			ValMinCpu:    node.Consensus.SidecarCpuRequired,
-			ValMaxCpu:    node.Consensus.SidecarCpuRequired,
+			ValMaxCpu:    limitOrRequired(node.Consensus.SidecarCpuLimit, node.Consensus.SidecarCpuRequired),
 			ValMinMemory: node.Consensus.SidecarMemoryRequired,
-			ValMaxMemory: node.Consensus.SidecarMemoryRequired,
+			ValMaxMemory: limitOrRequired(node.Consensus.SidecarMemoryLimit, node.Consensus.SidecarMemoryRequired),
+
+			ElVolumeSize: node.Execution.StorageSize,
+			ClVolumeSize: node.Consensus.StorageSize,
 			Count:        1,
 		}
 		participants[i] = p
@@ -122,3 +211,11 @@ func serializeNodes(nodes []*network.Node) []*Participant {
 
 	return participants
 }
+
+// limitOrRequired falls back to the request for nodes that never had a separate limit set.
+func limitOrRequired(limit, required int) int {
+	if limit == 0 {
+		return required
+	}
+	return limit
+}
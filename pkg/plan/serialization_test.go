@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"attacknet/cmd/pkg/plan/network"
+	"testing"
+)
+
+func TestSerializeResourceRanges(t *testing.T) {
+	nodes := []*network.Node{{
+		Index:          1,
+		ConsensusVotes: 8,
+		Execution: &network.ExecutionClient{
+			Type: "geth", Image: "geth:latest", ExtraLabels: map[string]string{},
+			CpuRequired: 500, CpuLimit: 2000, MemoryRequired: 1024, MemoryLimit: 4096,
+		},
+		Consensus: &network.ConsensusClient{
+			Type: "lighthouse", Image: "lighthouse:latest", HasValidatorSidecar: true, ExtraLabels: map[string]string{},
+			CpuRequired: 1000, CpuLimit: 1000, MemoryRequired: 1536, MemoryLimit: 3072,
+			SidecarCpuRequired: 250, SidecarCpuLimit: 500, SidecarMemoryRequired: 256, SidecarMemoryLimit: 512,
+		},
+	}}
+
+	participants := serializeNodes(nodes)
+	p := participants[0]
+	if p.ElMinCpu != 500 || p.ElMaxCpu != 2000 || p.ElMinMemory != 1024 || p.ElMaxMemory != 4096 {
+		t.Fatalf("unexpected el resources %+v", p)
+	}
+	if p.ClMinCpu != 1000 || p.ClMaxCpu != 1000 || p.ClMinMemory != 1536 || p.ClMaxMemory != 3072 {
+		t.Fatalf("unexpected cl resources %+v", p)
+	}
+	if p.ValMinCpu != 250 || p.ValMaxCpu != 500 || p.ValMinMemory != 256 || p.ValMaxMemory != 512 {
+		t.Fatalf("unexpected vc resources %+v", p)
+	}
+
+	bs, err := SerializeNetworkTopology(nodes, &network.GenesisConfig{NumValKeysPerNode: 8}, &NetworkFeatures{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTripped, err := DeserializeNetworkTopology(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	el := roundTripped[0].Execution
+	cl := roundTripped[0].Consensus
+	if el.CpuRequired != 500 || el.CpuLimit != 2000 || el.MemoryRequired != 1024 || el.MemoryLimit != 4096 {
+		t.Fatalf("el resources didn't round-trip: %+v", el)
+	}
+	if cl.SidecarCpuRequired != 250 || cl.SidecarCpuLimit != 500 || cl.MemoryLimit != 3072 {
+		t.Fatalf("cl resources didn't round-trip: %+v", cl)
+	}
+}
+
+func TestSerializeLimitFallsBackToRequest(t *testing.T) {
+	nodes := []*network.Node{{
+		Index: 1,
+		Execution: &network.ExecutionClient{
+			Type: "geth", ExtraLabels: map[string]string{}, CpuRequired: 768, MemoryRequired: 1024,
+		},
+		Consensus: &network.ConsensusClient{
+			Type: "lighthouse", ExtraLabels: map[string]string{}, CpuRequired: 1000, MemoryRequired: 1536,
+		},
+	}}
+	p := serializeNodes(nodes)[0]
+	if p.ElMaxCpu != 768 || p.ElMaxMemory != 1024 || p.ClMaxCpu != 1000 || p.ClMaxMemory != 1536 {
+		t.Fatalf("expected unset limits to fall back to the request, got %+v", p)
+	}
+}
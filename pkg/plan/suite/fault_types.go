@@ -0,0 +1,166 @@
+package suite
+
+import (
+	"attacknet/cmd/pkg/types"
+	"fmt"
+	"github.com/kurtosis-tech/stacktrace"
+	"time"
+)
+
+// FaultTiming holds the fields shared by every fault dimension.
+type FaultTiming struct {
+	Duration    time.Duration `yaml:"duration"`     // how long the fault is injected for
+	GracePeriod time.Duration `yaml:"grace_period"` // how long to wait for health checks to pass
+}
+
+func (t FaultTiming) validate(requireDuration bool) error {
+	if requireDuration && t.Duration <= 0 {
+		return stacktrace.NewError("duration must be > 0")
+	}
+	if t.GracePeriod <= 0 {
+		return stacktrace.NewError("grace_period must be > 0")
+	}
+	return nil
+}
+
+func validatePercent(field string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return stacktrace.NewError("%s must be between 0 and 100, got %d", field, percent)
+	}
+	return nil
+}
+
+type ClockSkewDimension struct {
+	Skew        string `yaml:"skew"` // how far to move the clock. can be negative
+	FaultTiming `yaml:",inline"`
+}
+
+func (d *ClockSkewDimension) Validate() error {
+	if _, err := time.ParseDuration(d.Skew); err != nil {
+		return stacktrace.NewError("skew must be a duration such as -2m, got '%s'", d.Skew)
+	}
+	return d.FaultTiming.validate(true)
+}
+
+type clockSkewFault struct{}
+
+func (clockSkewFault) NewDimension() FaultDimension {
+	return &ClockSkewDimension{}
+}
+
+func (clockSkewFault) ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error) {
+	d := dimension.(*ClockSkewDimension)
+	description := fmt.Sprintf("Apply %s clock skew for %s against %d targets. %s", d.Skew, d.Duration, len(targets), targetingDescription)
+	return ComposeNodeClockSkewTest(description, targets, d.Skew, d.Duration.String(), &d.GracePeriod)
+}
+
+type ContainerRestartDimension struct {
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+func (d *ContainerRestartDimension) Validate() error {
+	return FaultTiming{GracePeriod: d.GracePeriod}.validate(false)
+}
+
+type containerRestartFault struct{}
+
+func (containerRestartFault) NewDimension() FaultDimension {
+	return &ContainerRestartDimension{}
+}
+
+func (containerRestartFault) ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error) {
+	d := dimension.(*ContainerRestartDimension)
+	description := fmt.Sprintf("Restarting %d targets. %s", len(targets), targetingDescription)
+	return composeNodeRestartTest(description, targets, &d.GracePeriod)
+}
+
+type IOLatencyDimension struct {
+	Delay       time.Duration `yaml:"delay"`   // latency added to i/o calls
+	Percent     int           `yaml:"percent"` // percent of i/o calls impacted
+	FaultTiming `yaml:",inline"`
+}
+
+func (d *IOLatencyDimension) Validate() error {
+	if d.Delay < 0 {
+		return stacktrace.NewError("delay must be >= 0")
+	}
+	if err := validatePercent("percent", d.Percent); err != nil {
+		return err
+	}
+	return d.FaultTiming.validate(true)
+}
+
+type ioLatencyFault struct{}
+
+func (ioLatencyFault) NewDimension() FaultDimension {
+	return &IOLatencyDimension{}
+}
+
+func (ioLatencyFault) ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error) {
+	d := dimension.(*IOLatencyDimension)
+	description := fmt.Sprintf("Apply %s i/o latency for %s. Impacting %d pct of i/o calls. against %d targets. %s", d.Delay, d.Duration, d.Percent, len(targets), targetingDescription)
+	return composeIOLatencyTest(description, targets, &d.Delay, d.Percent, &d.Duration, &d.GracePeriod)
+}
+
+type NetworkLatencyDimension struct {
+	Delay       time.Duration `yaml:"delay"`       // latency added to packets
+	Jitter      time.Duration `yaml:"jitter"`      // variation of the latency
+	Correlation int           `yaml:"correlation"` // percent correlation between the latency of consecutive packets
+	FaultTiming `yaml:",inline"`
+}
+
+func (d *NetworkLatencyDimension) Validate() error {
+	if d.Delay < 0 || d.Jitter < 0 {
+		return stacktrace.NewError("delay and jitter must be >= 0")
+	}
+	if err := validatePercent("correlation", d.Correlation); err != nil {
+		return err
+	}
+	return d.FaultTiming.validate(true)
+}
+
+type networkLatencyFault struct{}
+
+func (networkLatencyFault) NewDimension() FaultDimension {
+	return &NetworkLatencyDimension{}
+}
+
+func (networkLatencyFault) ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error) {
+	d := dimension.(*NetworkLatencyDimension)
+	description := fmt.Sprintf("Apply %s network latency for %s. Jitter: %s, correlation: %d against %d targets. %s", d.Delay, d.Duration, d.Jitter, d.Correlation, len(targets), targetingDescription)
+	return ComposeNetworkLatencyTest(description, targets, &d.Delay, &d.Jitter, &d.Duration, &d.GracePeriod, d.Correlation)
+}
+
+var packetLossDirections = map[string]bool{
+	"to":   true,
+	"from": true,
+	"both": true,
+}
+
+type PacketLossDimension struct {
+	LossPercent int    `yaml:"loss_percent"` // percent of packets dropped
+	Direction   string `yaml:"direction"`    // to, from or both
+	FaultTiming `yaml:",inline"`
+}
+
+func (d *PacketLossDimension) Validate() error {
+	if err := validatePercent("loss_percent", d.LossPercent); err != nil {
+		return err
+	}
+	if !packetLossDirections[d.Direction] {
+		return stacktrace.NewError("direction must be one of to, from or both, got '%s'", d.Direction)
+	}
+	return d.FaultTiming.validate(true)
+}
+
+type packetLossFault struct{}
+
+func (packetLossFault) NewDimension() FaultDimension {
+	return &PacketLossDimension{}
+}
+
+func (packetLossFault) ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error) {
+	d := dimension.(*PacketLossDimension)
+	description := fmt.Sprintf("Apply %d packet drop for %s, direction: %s against %d targets. %s", d.LossPercent, d.Duration, d.Direction, len(targets), targetingDescription)
+	return ComposePacketDropTest(description, targets, d.LossPercent, d.Direction, &d.Duration, &d.GracePeriod)
+}
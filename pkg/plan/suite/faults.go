@@ -232,6 +232,9 @@ func buildPodRestartFault(description string, expressionSelectors []ChaosExpress
 func getVolumePathForIOFault(podName string) (string, error) {
 	var nodeType string
 	parts := strings.Split(podName, "-")
+	if parts[0] == "mev" || parts[0] == "mock" {
+		return "", stacktrace.NewError("cannot create an i/o latency fault on a mev-boost or relay pod: %s", podName)
+	}
 	if parts[0] == "el" {
 		nodeType = "execution"
 	} else {
@@ -0,0 +1,148 @@
+package suite
+
+import (
+	"attacknet/cmd/pkg/types"
+	"github.com/kurtosis-tech/stacktrace"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FaultDimension is a single fault_config_dimensions entry, decoded into the struct declared by its fault type.
+type FaultDimension interface {
+	// Validate range-checks the decoded values.
+	Validate() error
+}
+
+// FaultType is a fault the planner can build tests for. Fault types are looked up by the fault_type of the planner
+// config, so adding one only requires registering it with RegisterFaultType.
+type FaultType interface {
+	// NewDimension returns a pointer to the empty struct fault_config_dimensions entries are decoded into. The yaml
+	// tags of the struct define which keys are accepted. Every key is required unless it's tagged omitempty.
+	NewDimension() FaultDimension
+	// ComposeTest builds a test that injects the fault into the targets.
+	ComposeTest(dimension FaultDimension, targets []*ChaosTargetSelector, targetingDescription string) (*types.SuiteTest, error)
+}
+
+var faultTypeRegistry = map[FaultTypeEnum]FaultType{
+	FaultClockSkew:        clockSkewFault{},
+	FaultContainerRestart: containerRestartFault{},
+	FaultIOLatency:        ioLatencyFault{},
+	FaultNetworkLatency:   networkLatencyFault{},
+	FaultPacketLoss:       packetLossFault{},
+}
+
+// RegisterFaultType makes a fault type available to planner configs under the given name. The registry isn't guarded,
+// so fault types must be registered from init functions.
+func RegisterFaultType(name FaultTypeEnum, faultType FaultType) error {
+	if _, exists := faultTypeRegistry[name]; exists {
+		return stacktrace.NewError("fault type %s is already registered", name)
+	}
+	faultTypeRegistry[name] = faultType
+	return nil
+}
+
+func LookupFaultType(name FaultTypeEnum) (FaultType, error) {
+	faultType, ok := faultTypeRegistry[name]
+	if !ok {
+		return nil, stacktrace.NewError("the fault type '%s' is not supported. Supported faults: %v", name, RegisteredFaultTypes())
+	}
+	return faultType, nil
+}
+
+// RegisteredFaultTypes returns the names of every registered fault type, sorted.
+func RegisteredFaultTypes() []FaultTypeEnum {
+	var names []FaultTypeEnum
+	for name := range faultTypeRegistry {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// DecodeFaultDimension strictly decodes a fault_config_dimensions entry into the dimension struct of the fault type.
+// Errors include the line of the entry in the planner config.
+func DecodeFaultDimension(faultType FaultType, node *yaml.Node) (FaultDimension, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, stacktrace.NewError("line %d: fault_config_dimensions entries must be maps", node.Line)
+	}
+
+	dimension := faultType.NewDimension()
+	keys, optional := dimensionKeys(reflect.TypeOf(dimension).Elem())
+	known := make(map[string]bool)
+	for _, key := range keys {
+		known[key] = true
+	}
+	present := make(map[string]bool)
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if !known[keyNode.Value] {
+			return nil, stacktrace.NewError("line %d: unknown field %s. Supported fields: %s", keyNode.Line, keyNode.Value, strings.Join(keys, ", "))
+		}
+		present[keyNode.Value] = true
+	}
+	// zero values are valid for most fields, so a missing field can't be caught by Validate
+	for _, key := range keys {
+		if !present[key] && !optional[key] {
+			return nil, stacktrace.NewError("line %d: missing %s field", node.Line, key)
+		}
+	}
+
+	err := node.Decode(dimension)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "unable to decode fault_config_dimensions entry on line %d", node.Line)
+	}
+	err = dimension.Validate()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "line %d: invalid fault_config_dimensions entry", node.Line)
+	}
+	return dimension, nil
+}
+
+// DecodeDimensions decodes every fault_config_dimensions entry using the configured fault type.
+func (c *PlannerFaultConfiguration) DecodeDimensions() error {
+	faultType, err := LookupFaultType(c.FaultType)
+	if err != nil {
+		return err
+	}
+	c.Dimensions = nil
+	for i := range c.FaultConfigDimensions {
+		dimension, err := DecodeFaultDimension(faultType, &c.FaultConfigDimensions[i])
+		if err != nil {
+			return err
+		}
+		c.Dimensions = append(c.Dimensions, dimension)
+	}
+	return nil
+}
+
+// dimensionKeys lists the yaml keys of a dimension struct, including the keys of inlined structs, and which of them
+// are optional.
+func dimensionKeys(t reflect.Type) ([]string, map[string]bool) {
+	var keys []string
+	optional := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(options, "inline") && field.Type.Kind() == reflect.Struct {
+			inlineKeys, inlineOptional := dimensionKeys(field.Type)
+			keys = append(keys, inlineKeys...)
+			for key := range inlineOptional {
+				optional[key] = true
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		keys = append(keys, name)
+		if strings.Contains(options, "omitempty") {
+			optional[name] = true
+		}
+	}
+	return keys, optional
+}
@@ -0,0 +1,85 @@
+package suite
+
+import (
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+)
+
+func decodeTestDimension(t *testing.T, faultType FaultTypeEnum, entry string) (FaultDimension, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(entry), &doc); err != nil {
+		t.Fatal(err)
+	}
+	ft, err := LookupFaultType(faultType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return DecodeFaultDimension(ft, doc.Content[0])
+}
+
+func TestDecodeFaultDimension(t *testing.T) {
+	dimension, err := decodeTestDimension(t, FaultPacketLoss, "loss_percent: 50\ndirection: both\nduration: 1m\ngrace_period: 300s\n")
+	if err != nil {
+		t.Fatalf("expected dimension to be valid, got %s", err)
+	}
+	packetLoss := dimension.(*PacketLossDimension)
+	if packetLoss.LossPercent != 50 || packetLoss.Direction != "both" || packetLoss.GracePeriod.Seconds() != 300 {
+		t.Fatalf("dimension decoded incorrectly: %+v", packetLoss)
+	}
+
+	type testCase struct {
+		Entry         string
+		ExpectedError string
+	}
+	testCases := []testCase{
+		{"loss_percent: 50\ndirection: both\nduration: 1m\n", "grace_period"},
+		{"loss_percent: 150\ndirection: both\nduration: 1m\ngrace_period: 300s\n", "between 0 and 100"},
+		{"loss_percent: 50\ndirection: sideways\nduration: 1m\ngrace_period: 300s\n", "direction"},
+		{"loss_percent: 50\ndirection: both\nduration: 1m\ngrace_period: 300s\nloss: 10\n", "line 5: unknown field loss"},
+		{"loss_percent: lots\ndirection: both\nduration: 1m\ngrace_period: 300s\n", "line 1"},
+	}
+	for _, test := range testCases {
+		_, err = decodeTestDimension(t, FaultPacketLoss, test.Entry)
+		if err == nil || !strings.Contains(err.Error(), test.ExpectedError) {
+			t.Fatalf("expected an error containing '%s' for entry %q, got %v", test.ExpectedError, test.Entry, err)
+		}
+	}
+}
+
+func TestDecodeFaultDimensionMissingFields(t *testing.T) {
+	type testCase struct {
+		FaultType     FaultTypeEnum
+		Entry         string
+		ExpectedError string
+	}
+	testCases := []testCase{
+		{FaultClockSkew, "duration: 1m\ngrace_period: 300s\n", "line 1: missing skew field"},
+		{FaultContainerRestart, "{}\n", "line 1: missing grace_period field"},
+		{FaultIOLatency, "delay: 100ms\nduration: 1m\ngrace_period: 300s\n", "line 1: missing percent field"},
+		{FaultIOLatency, "percent: 50\nduration: 1m\ngrace_period: 300s\n", "line 1: missing delay field"},
+		{FaultNetworkLatency, "jitter: 10ms\ncorrelation: 50\nduration: 1m\ngrace_period: 300s\n", "line 1: missing delay field"},
+		{FaultNetworkLatency, "delay: 100ms\ncorrelation: 50\nduration: 1m\ngrace_period: 300s\n", "line 1: missing jitter field"},
+		{FaultNetworkLatency, "delay: 100ms\njitter: 10ms\nduration: 1m\ngrace_period: 300s\n", "line 1: missing correlation field"},
+		{FaultPacketLoss, "direction: both\nduration: 1m\ngrace_period: 300s\n", "line 1: missing loss_percent field"},
+		{FaultPacketLoss, "loss_percent: 50\ndirection: both\ngrace_period: 300s\n", "line 1: missing duration field"},
+	}
+	for _, test := range testCases {
+		_, err := decodeTestDimension(t, test.FaultType, test.Entry)
+		if err == nil || !strings.Contains(err.Error(), test.ExpectedError) {
+			t.Errorf("%s: expected an error containing '%s' for entry %q, got %v", test.FaultType, test.ExpectedError, test.Entry, err)
+		}
+	}
+
+	// zero values are fine as long as they're set
+	_, err := decodeTestDimension(t, FaultNetworkLatency, "delay: 0s\njitter: 0s\ncorrelation: 0\nduration: 1m\ngrace_period: 300s\n")
+	if err != nil {
+		t.Fatalf("expected explicit zero values to be valid, got %s", err)
+	}
+}
+
+func TestRegisterFaultType(t *testing.T) {
+	if err := RegisterFaultType(FaultClockSkew, clockSkewFault{}); err == nil {
+		t.Fatal("expected registering a duplicate fault type to fail")
+	}
+}
@@ -15,6 +15,7 @@ const (
 	Execution clientType = "execution"
 	Consensus clientType = "consensus"
 	Validator clientType = "validator"
+	MevBoost  clientType = "mev-boost"
 )
 
 func ConvertToNodeIdTag(networkNodeCount int, node *network.Node, client clientType) string {
@@ -35,6 +36,8 @@ func ConvertToNodeIdTag(networkNodeCount int, node *network.Node, client clientT
 		return fmt.Sprintf("cl-%s-%s-%s", nodeNumStr, node.Consensus.Type, node.Execution.Type)
 	case Validator:
 		return fmt.Sprintf("val-%s-%s-%s", nodeNumStr, node.Consensus.Type, node.Execution.Type)
+	case MevBoost:
+		return fmt.Sprintf("mev-boost-%s-%s-%s", nodeNumStr, node.Consensus.Type, node.Execution.Type)
 	default:
 		log.Errorf("Unrecognized node type %s", client)
 		return ""
@@ -4,30 +4,43 @@ import (
 	"attacknet/cmd/pkg/plan/network"
 	"attacknet/cmd/pkg/types"
 	"fmt"
-	"github.com/kurtosis-tech/stacktrace"
 	log "github.com/sirupsen/logrus"
-	"strconv"
-	"time"
 )
 
 func ComposeTestSuite(
 	config PlannerFaultConfiguration,
 	isExecClient bool,
-	nodes []*network.Node) ([]types.SuiteTest, error) {
+	nodes []*network.Node,
+	networkStake int,
+	mevType string) ([]types.SuiteTest, error) {
 
 	var tests []types.SuiteTest
-	runtimeEstimate := 0
 
-	nodeFilter := BuildNodeFilteringLambda(config.TargetClient, isExecClient)
+	faultType, err := LookupFaultType(config.FaultType)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSelector := NewTargetSelector(config)
+	nodeFilter := BuildNodeFilteringLambda(config.TargetClient, isExecClient, targetSelector)
 
 	for _, targetDimension := range config.TargetingDimensions {
+		// the relay is shared by every node, so attack sizes don't apply to it
+		if targetDimension == TargetMatchingRelay {
+			relayTests, err := composeRelayTests(faultType, config.Dimensions, mevType)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, relayTests...)
+			continue
+		}
 		targetFilter, err := TargetSpecEnumToLambda(targetDimension, isExecClient)
 		if err != nil {
 			return nil, err
 		}
-		nodeCountsTested := make(map[int]bool)
+		scopesTested := make(map[string]bool)
 		for _, attackSize := range config.AttackSizeDimensions {
-			targetSelectors, err := BuildChaosMeshTargetSelectors(len(nodes)+1, nodes, attackSize, nodeFilter, targetFilter)
+			targetSets, err := nodeFilter(attackSize, len(nodes)+1, networkStake, nodes)
 			if err != nil {
 				cannotMeet, ok := err.(CannotMeetConstraintError)
 				if !ok {
@@ -37,193 +50,63 @@ func ComposeTestSuite(
 				continue
 			}
 			// deduplicate attack sizes that produce the same scope
-			_, alreadyTested := nodeCountsTested[len(targetSelectors)]
+			scope := describeAttackScope(targetSets[0])
+			_, alreadyTested := scopesTested[scope]
 			if alreadyTested {
 				continue
 			} else {
-				nodeCountsTested[len(targetSelectors)] = true
+				scopesTested[scope] = true
 			}
 
-			for _, faultConfig := range config.FaultConfigDimensions {
-				// update runtime estimate. find better way
-				duration, ok := faultConfig["duration"]
-				if ok {
-					d, err := time.ParseDuration(duration)
-					if err == nil {
-						runtimeEstimate += int(d.Seconds())
+			for setIndex, targets := range targetSets {
+				targetSelectors := buildTargetSelectors(len(nodes)+1, targets, targetFilter)
+
+				for _, faultConfig := range config.Dimensions {
+					var targetingDescription string
+					switch targetDimension {
+					case TargetMatchingNode:
+						targetingDescription = fmt.Sprintf("Impacting the full node of targeted %s clients. Injecting into %s of the matching targets.", config.TargetClient, attackSize)
+					case TargetMatchingMevBoost:
+						targetingDescription = fmt.Sprintf("Impacting the mev-boost sidecar of targeted %s clients. Injecting into %s of the matching targets.", config.TargetClient, attackSize)
+					default:
+						targetingDescription = fmt.Sprintf("Impacting the client of targeted %s clients. Injecting into %s of the matching targets.", config.TargetClient, attackSize)
+					}
+					if len(targetSets) > 1 {
+						targetingDescription = fmt.Sprintf("%s Target combination %d of %d.", targetingDescription, setIndex+1, len(targetSets))
 					}
-				}
-				var targetingDescription string
-				if targetDimension == TargetMatchingNode {
-					targetingDescription = fmt.Sprintf("Impacting the full node of targeted %s clients. Injecting into %s of the matching targets.", config.TargetClient, attackSize)
-				} else {
-					targetingDescription = fmt.Sprintf("Impacting the client of targeted %s clients. Injecting into %s of the matching targets.", config.TargetClient, attackSize)
-				}
 
-				test, err := composeTestForFaultType(
-					config.FaultType,
-					faultConfig,
-					targetSelectors,
-					targetingDescription,
-				)
-				if err != nil {
-					return nil, err
+					test, err := faultType.ComposeTest(faultConfig, targetSelectors, targetingDescription)
+					if err != nil {
+						return nil, err
+					}
+					tests = append(tests, *test)
 				}
-				tests = append(tests, *test)
 			}
 		}
 	}
 	log.Infof("Tests generated: %d", len(tests))
-	log.Infof("ESTIMATE: Running this test suite will take, at minimum, %d minutes based on fault durations.", runtimeEstimate/60)
 
 	return tests, nil
 }
 
-func getDurationValue(key string, m map[string]string) (*time.Duration, error) {
-
-	valueStr, ok := m[key]
-	if !ok {
-		return nil, stacktrace.NewError("missing %s field", key)
-	}
-	duration, err := time.ParseDuration(valueStr)
-	if err != nil {
-		return nil, stacktrace.NewError("unable to convert %s field to a time duration", key)
-	}
-	return &duration, nil
-}
-
-func getUintValue(key string, m map[string]string) (uint32, error) {
-	valueStr, ok := m[key]
-	if !ok {
-		return 0, stacktrace.NewError("missing %s field", key)
-	}
-	uintValue, err := strconv.ParseUint(valueStr, 10, 32)
-	if err != nil {
-		return 0, stacktrace.NewError("unable to convert %s field to a uint32", key)
-	}
-	return uint32(uintValue), nil
-}
-
-func getFloat32Value(key string, m map[string]string) (float32, error) {
-	valueStr, ok := m[key]
-	if !ok {
-		return 0, stacktrace.NewError("missing %s field", key)
-	}
-	floatValue, err := strconv.ParseFloat(valueStr, 32)
+func composeRelayTests(faultType FaultType, dimensions []FaultDimension, mevType string) ([]types.SuiteTest, error) {
+	selector, err := createTargetSelectorForRelay(mevType)
 	if err != nil {
-		return 0, stacktrace.NewError("unable to convert %s field to a uint32", key)
-	}
-	return float32(floatValue), nil
-}
-
-func getStringValue(key string, m map[string]string) (string, error) {
-	valueStr, ok := m[key]
-	if !ok {
-		return "", stacktrace.NewError("missing %s field", key)
+		return nil, err
 	}
-	return valueStr, nil
-}
-
-func composeTestForFaultType(
-	faultType FaultTypeEnum,
-	config map[string]string,
-	targetSelectors []*ChaosTargetSelector,
-	targetingDescription string,
-) (*types.SuiteTest, error) {
-
-	switch faultType {
-	case FaultClockSkew:
-		skew, ok := config["skew"]
-		if !ok {
-			return nil, stacktrace.NewError("missing skew field for clock skew fault")
-		}
-		duration, ok := config["duration"]
-		if !ok {
-			return nil, stacktrace.NewError("missing duration field for clock skew fault")
-		}
-		graceDuration, err := getDurationValue("grace_period", config)
-		if err != nil {
-			return nil, err
-		}
-
-		description := fmt.Sprintf("Apply %s clock skew for %s against %d targets. %s", skew, duration, len(targetSelectors), targetingDescription)
-		return ComposeNodeClockSkewTest(description, targetSelectors, skew, duration, graceDuration)
-	case FaultContainerRestart:
+	targetingDescription := fmt.Sprintf("Impacting the %s relay used by every node's mev-boost.", mevType)
 
-		graceDuration, err := getDurationValue("grace_period", config)
-		if err != nil {
-			return nil, err
-		}
-		description := fmt.Sprintf("Restarting %d targets. %s", len(targetSelectors), targetingDescription)
-		return composeNodeRestartTest(description, targetSelectors, graceDuration)
-	case FaultIOLatency:
-		grace, err := getDurationValue("grace_period", config)
-		if err != nil {
-			return nil, err
-		}
-		delay, err := getDurationValue("delay", config)
-		if err != nil {
-			return nil, err
-		}
-		faultDuration, err := getDurationValue("duration", config)
-		if err != nil {
-			return nil, err
-		}
-
-		percent, ok := config["percent"]
-		if !ok {
-			return nil, stacktrace.NewError("missing percent field in io latency fault")
-		}
-		percentInt, err := strconv.Atoi(percent)
-		if err != nil {
-			return nil, stacktrace.Propagate(err, "unable to parse io latency fault percent field")
-		}
-		description := fmt.Sprintf("Apply %s i/o latency for %s. Impacting %d pct of i/o calls. against %d targets. %s", delay, faultDuration, percentInt, len(targetSelectors), targetingDescription)
-
-		return composeIOLatencyTest(description, targetSelectors, delay, percentInt, faultDuration, grace)
-	case FaultNetworkLatency:
-		grace, err := getDurationValue("grace_period", config)
-		if err != nil {
-			return nil, err
-		}
-		delay, err := getDurationValue("delay", config)
-		if err != nil {
-			return nil, err
-		}
-		jitter, err := getDurationValue("jitter", config)
-		if err != nil {
-			return nil, err
-		}
-		duration, err := getDurationValue("duration", config)
-		if err != nil {
-			return nil, err
-		}
-		correlation, err := getUintValue("correlation", config)
-		if err != nil {
-			return nil, err
-		}
-		description := fmt.Sprintf("Apply %s network latency for %s. Jitter: %s, correlation: %d against %d targets. %s", delay, duration, jitter, correlation, len(targetSelectors), targetingDescription)
-		return ComposeNetworkLatencyTest(description, targetSelectors, delay, jitter, duration, grace, int(correlation))
-	case FaultPacketLoss:
-		grace, err := getDurationValue("grace_period", config)
-		if err != nil {
-			return nil, err
-		}
-		duration, err := getDurationValue("duration", config)
-		if err != nil {
-			return nil, err
-		}
-		lossPercent, err := getUintValue("loss_percent", config)
-		if err != nil {
-			return nil, err
-		}
-		direction, err := getStringValue("direction", config)
+	var tests []types.SuiteTest
+	for _, faultConfig := range dimensions {
+		test, err := faultType.ComposeTest(faultConfig, []*ChaosTargetSelector{selector}, targetingDescription)
 		if err != nil {
 			return nil, err
 		}
-		description := fmt.Sprintf("Apply %d packet drop for %s, direction: %s against %d targets. %s", lossPercent, duration, direction, len(targetSelectors), targetingDescription)
-		return ComposePacketDropTest(description, targetSelectors, int(lossPercent), direction, duration, grace)
+		tests = append(tests, *test)
 	}
+	return tests, nil
+}
 
-	return nil, nil
+func describeAttackScope(targets []*network.Node) string {
+	return fmt.Sprintf("%d nodes/%d validator keys", len(targets), network.CountConsensusVotes(targets))
 }
@@ -0,0 +1,59 @@
+package suite
+
+import (
+	"attacknet/cmd/pkg/plan/network"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComposeRelayTests(t *testing.T) {
+	config := PlannerFaultConfiguration{
+		FaultType:            FaultNetworkLatency,
+		TargetClient:         "geth",
+		TargetingDimensions:  []TargetingSpec{TargetMatchingRelay},
+		AttackSizeDimensions: []AttackSize{AttackOne, AttackAll},
+		Dimensions: []FaultDimension{
+			&NetworkLatencyDimension{Delay: time.Second, FaultTiming: FaultTiming{Duration: time.Minute}},
+			&NetworkLatencyDimension{Delay: 2 * time.Second, FaultTiming: FaultTiming{Duration: time.Minute}},
+		},
+	}
+	nodes := NewMockNetworkWithStake(8, 8, 8, 8)
+	for _, node := range nodes {
+		node.Execution = &network.ExecutionClient{Type: "geth"}
+		node.Consensus = &network.ConsensusClient{Type: "lighthouse", HasValidatorSidecar: true}
+	}
+
+	expected := map[string][]string{
+		"mock": {"mock-mev"},
+		"full": {"mev-relay-api", "mev-relay-housekeeper"},
+	}
+	for mevType, services := range expected {
+		tests, err := ComposeTestSuite(config, true, nodes, 40, mevType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// one test per fault dimension, regardless of attack sizes
+		if len(tests) != 2 {
+			t.Fatalf("%s: expected 2 relay tests, got %d", mevType, len(tests))
+		}
+		bs, err := yaml.Marshal(tests[0].PlanSteps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, service := range services {
+			if !strings.Contains(string(bs), service) {
+				t.Fatalf("%s: expected the fault to select %s, got:\n%s", mevType, service, bs)
+			}
+		}
+		if strings.Contains(string(bs), "el-") || strings.Contains(string(bs), "cl-") {
+			t.Fatalf("%s: expected the fault to select only the relay, got:\n%s", mevType, bs)
+		}
+	}
+
+	_, err := ComposeTestSuite(config, true, nodes, 40, "unknown")
+	if err == nil {
+		t.Fatal("expected an unsupported mev_type to be rejected")
+	}
+}
@@ -4,6 +4,12 @@ import (
 	"attacknet/cmd/pkg/plan/network"
 	"fmt"
 	"github.com/kurtosis-tech/stacktrace"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type ChaosTargetSelector struct {
@@ -21,17 +27,175 @@ func (e CannotMeetConstraintError) Error() string {
 }
 
 type NodeFilterCriteria func(n *network.Node) bool
-type TargetCriteriaFilter func(AttackSize, int, []*network.Node) ([]*network.Node, error)
+type TargetCriteriaFilter func(size AttackSize, networkSize, networkStake int, nodes []*network.Node) ([][]*network.Node, error)
 type NodeImpactSelector func(networkNodeCount int, node *network.Node) *ChaosTargetSelector
 
-func BuildNodeFilteringLambda(clientType string, isExecClient bool) TargetCriteriaFilter {
+// stakeFractionEpsilon is the tolerance used when deciding whether a subset of nodes hits a stake fraction exactly.
+const stakeFractionEpsilon = 1e-9
+
+// TargetSelector decides which of the matching nodes are targeted once an attack size has been resolved. It carries
+// state between calls so round-robin selection keeps rotating across tests.
+type TargetSelector struct {
+	mode             TargetSelectionMode
+	stakePolicy      StakeFractionPolicy
+	maxCombinations  int
+	rng              *rand.Rand
+	roundRobinOffset int
+}
+
+func NewTargetSelector(config PlannerFaultConfiguration) *TargetSelector {
+	mode := config.TargetSelection
+	if mode == "" {
+		mode = TargetSelectFirst
+	}
+	maxCombinations := config.MaxTargetCombinations
+	if maxCombinations == 0 {
+		maxCombinations = defaultMaxTargetCombinations
+	}
+	return &TargetSelector{
+		mode:            mode,
+		stakePolicy:     config.StakeFractionPolicy,
+		maxCombinations: maxCombinations,
+		rng:             rand.New(rand.NewSource(config.TargetSelectionSeed)), // #nosec G404 -- reproducible plans, not security
+	}
+}
+
+// SelectTargets resolves the attack size against the targetable nodes and returns one or more target sets to test.
+// Only all_combinations mode returns more than one set.
+func (t *TargetSelector) SelectTargets(size AttackSize, networkSize, networkStake int, targetable []*network.Node) ([][]*network.Node, error) {
+	switch t.mode {
+	case TargetSelectFirst:
+		targets, err := chooseTargetsUsingAttackSize(size, t.stakePolicy, networkSize, networkStake, targetable)
+		if err != nil {
+			return nil, err
+		}
+		return [][]*network.Node{targets}, nil
+	case TargetSelectRandom:
+		shuffled := make([]*network.Node, len(targetable))
+		copy(shuffled, targetable)
+		t.rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		targets, err := chooseTargetsUsingAttackSize(size, t.stakePolicy, networkSize, networkStake, shuffled)
+		if err != nil {
+			return nil, err
+		}
+		return [][]*network.Node{targets}, nil
+	case TargetSelectRoundRobin:
+		rotated := make([]*network.Node, 0, len(targetable))
+		if len(targetable) > 0 {
+			offset := t.roundRobinOffset % len(targetable)
+			rotated = append(rotated, targetable[offset:]...)
+			rotated = append(rotated, targetable[:offset]...)
+		}
+		targets, err := chooseTargetsUsingAttackSize(size, t.stakePolicy, networkSize, networkStake, rotated)
+		if err != nil {
+			return nil, err
+		}
+		t.roundRobinOffset += len(targets)
+		return [][]*network.Node{targets}, nil
+	case TargetSelectAllCombinations:
+		// resolve the scope of the attack first, then enumerate every node subset with the same scope.
+		targets, err := chooseTargetsUsingAttackSize(size, t.stakePolicy, networkSize, networkStake, targetable)
+		if err != nil {
+			return nil, err
+		}
+		// ask for one more than the cap so we only report the cap when combinations were actually dropped
+		var combinations [][]*network.Node
+		if _, isStake, _ := ParseStakeAttackSize(size); isStake {
+			combinations = combinationsWithStake(targetable, network.CountConsensusVotes(targets), t.maxCombinations+1)
+		} else {
+			combinations = combinationsOfSize(targetable, len(targets), t.maxCombinations+1)
+		}
+		if len(combinations) > t.maxCombinations {
+			log.Infof("Attack size %s was capped at %d target combinations. Raise max_target_combinations to generate more", size, t.maxCombinations)
+			combinations = combinations[:t.maxCombinations]
+		}
+		return combinations, nil
+	default:
+		return nil, stacktrace.NewError("target selection mode %s not supported", t.mode)
+	}
+}
+
+// combinationsOfSize returns up to limit distinct subsets of nodes containing exactly k nodes, in lexicographic order.
+func combinationsOfSize(nodes []*network.Node, k, limit int) [][]*network.Node {
+	var result [][]*network.Node
+	var current []*network.Node
+	var walk func(start int)
+	walk = func(start int) {
+		if len(result) >= limit {
+			return
+		}
+		if len(current) == k {
+			combination := make([]*network.Node, k)
+			copy(combination, current)
+			result = append(result, combination)
+			return
+		}
+		for i := start; i <= len(nodes)-(k-len(current)); i++ {
+			current = append(current, nodes[i])
+			walk(i + 1)
+			current = current[:len(current)-1]
+		}
+	}
+	walk(0)
+	return result
+}
+
+// combinationsWithStake returns up to limit distinct subsets of nodes holding exactly stake validator keys. Nodes
+// without validator keys are left out since they don't change the scope of the attack.
+func combinationsWithStake(nodes []*network.Node, stake, limit int) [][]*network.Node {
+	var result [][]*network.Node
+	var current []*network.Node
+	var walk func(start, remaining int)
+	walk = func(start, remaining int) {
+		if len(result) >= limit {
+			return
+		}
+		if remaining == 0 {
+			combination := make([]*network.Node, len(current))
+			copy(combination, current)
+			result = append(result, combination)
+			return
+		}
+		for i := start; i < len(nodes); i++ {
+			votes := nodes[i].ConsensusVotes
+			if votes <= 0 || votes > remaining {
+				continue
+			}
+			current = append(current, nodes[i])
+			walk(i+1, remaining-votes)
+			current = current[:len(current)-1]
+		}
+	}
+	walk(0, stake)
+	return result
+}
+
+func BuildNodeFilteringLambda(clientType string, isExecClient bool, selector *TargetSelector) TargetCriteriaFilter {
 	if isExecClient {
-		return filterNodesByExecClient(clientType)
+		return filterNodesByExecClient(clientType, selector)
 	} else {
-		return filterNodesByConsensusClient(clientType)
+		return filterNodesByConsensusClient(clientType, selector)
 	}
 }
 
+// ParseStakeAttackSize decodes attack sizes of the form stake:<fraction>. isStake is false for any other attack size.
+func ParseStakeAttackSize(size AttackSize) (fraction float64, isStake bool, err error) {
+	if !strings.HasPrefix(string(size), AttackSizeStakePrefix) {
+		return 0, false, nil
+	}
+	fractionStr := strings.TrimPrefix(string(size), AttackSizeStakePrefix)
+	fraction, err = strconv.ParseFloat(fractionStr, 64)
+	if err != nil {
+		return 0, true, stacktrace.NewError("unable to parse stake fraction in attack size %s", size)
+	}
+	if fraction <= 0 || fraction > 1 {
+		return 0, true, stacktrace.NewError("stake fraction in attack size %s must be >0 and <=1", size)
+	}
+	return fraction, true, nil
+}
+
 func filterNodes(nodes []*network.Node, criteria NodeFilterCriteria) []*network.Node {
 	var result []*network.Node
 	for _, n := range nodes {
@@ -42,7 +206,15 @@ func filterNodes(nodes []*network.Node, criteria NodeFilterCriteria) []*network.
 	return result
 }
 
-func chooseTargetsUsingAttackSize(size AttackSize, networkSize int, targetable []*network.Node) ([]*network.Node, error) {
+func chooseTargetsUsingAttackSize(size AttackSize, stakePolicy StakeFractionPolicy, networkSize, networkStake int, targetable []*network.Node) ([]*network.Node, error) {
+	fraction, isStake, err := ParseStakeAttackSize(size)
+	if err != nil {
+		return nil, err
+	}
+	if isStake {
+		return chooseTargetsUsingStakeFraction(size, fraction, stakePolicy, networkStake, targetable)
+	}
+
 	networkSizeFloat := float32(networkSize)
 	var nodesToTarget int
 	switch size {
@@ -110,6 +282,71 @@ func chooseTargetsUsingAttackSize(size AttackSize, networkSize int, targetable [
 	return targets, nil
 }
 
+// chooseTargetsUsingStakeFraction picks the targetable nodes whose combined validator keys make up the requested
+// fraction of the network's keys. Nodes earlier in the targetable list are preferred when several subsets qualify.
+func chooseTargetsUsingStakeFraction(size AttackSize, fraction float64, stakePolicy StakeFractionPolicy, networkStake int, targetable []*network.Node) ([]*network.Node, error) {
+	if networkStake <= 0 {
+		return nil, stacktrace.NewError("attack size %s requires validator keys to be assigned to the network's nodes", size)
+	}
+
+	// subset-sum over validator keys. via[s] is the node that first made a sum of s reachable and prev[s] is the sum
+	// that node was added to. Because nodes are visited in order, following prev yields the earliest matching nodes.
+	targetableStake := network.CountConsensusVotes(targetable)
+	reachable := make([]bool, targetableStake+1)
+	via := make([]int, targetableStake+1)
+	prev := make([]int, targetableStake+1)
+	reachable[0] = true
+	for i, node := range targetable {
+		if node.ConsensusVotes <= 0 {
+			continue
+		}
+		for sum := targetableStake; sum >= node.ConsensusVotes; sum-- {
+			if reachable[sum] || !reachable[sum-node.ConsensusVotes] {
+				continue
+			}
+			reachable[sum] = true
+			via[sum] = i
+			prev[sum] = sum - node.ConsensusVotes
+		}
+	}
+
+	requestedStake := fraction * float64(networkStake)
+	bestSum := 0
+	bestDistance := math.Inf(1)
+	for sum := 1; sum <= targetableStake; sum++ {
+		if !reachable[sum] {
+			continue
+		}
+		distance := math.Abs(float64(sum) - requestedStake)
+		// on ties, prefer the larger attack
+		if distance <= bestDistance {
+			bestDistance = distance
+			bestSum = sum
+		}
+	}
+
+	if bestSum == 0 {
+		return nil, CannotMeetConstraintError{
+			AttackSize:      size,
+			TargetableCount: len(targetable),
+		}
+	}
+
+	achieved := float64(bestSum) / float64(networkStake)
+	if math.Abs(achieved-fraction) > stakeFractionEpsilon {
+		if stakePolicy != StakeFractionClosest {
+			return nil, stacktrace.NewError("attack size %s cannot be hit exactly by the topology. The closest achievable stake fraction is %.4f (%d/%d validator keys). Set stake_fraction_policy to '%s' to use it", size, achieved, bestSum, networkStake, StakeFractionClosest)
+		}
+		log.Infof("Attack size %s cannot be hit exactly. Using the closest achievable stake fraction %.4f (%d/%d validator keys)", size, achieved, bestSum, networkStake)
+	}
+
+	var targets []*network.Node
+	for sum := bestSum; sum > 0; sum = prev[sum] {
+		targets = append([]*network.Node{targetable[via[sum]]}, targets...)
+	}
+	return targets, nil
+}
+
 func createTargetSelectorForNode(networkNodeCount int, node *network.Node) *ChaosTargetSelector {
 	var targets []string
 
@@ -175,6 +412,59 @@ func createTargetSelectorForConsensusClient(networkNodeCount int, node *network.
 	}
 }
 
+func createTargetSelectorForMevBoost(networkNodeCount int, node *network.Node) *ChaosTargetSelector {
+	mevBoostId := ConvertToNodeIdTag(networkNodeCount, node, MevBoost)
+	selector := ChaosExpressionSelector{
+		Key:      "kurtosistech.com/id",
+		Operator: "In",
+		Values:   []string{mevBoostId},
+	}
+
+	description := fmt.Sprintf("mev-boost of %s/%s Node (Node #%d)", node.Execution.Type, node.Consensus.Type, node.Index)
+	return &ChaosTargetSelector{
+		Selector:    []ChaosExpressionSelector{selector},
+		Description: description,
+	}
+}
+
+// relayServiceNames are the kurtosis service names the ethereum-package gives the relay for each mev_type. The mock
+// relay doubles as the builder, so faulting it exercises the whole builder path.
+var relayServiceNames = map[string][]string{
+	"mock":      {"mock-mev"},
+	"full":      {"mev-relay-api", "mev-relay-housekeeper"},
+	"flashbots": {"mev-relay-api", "mev-relay-housekeeper"},
+}
+
+// RelayServiceNames returns the relay services deployed for the mev_type.
+func RelayServiceNames(mevType string) ([]string, error) {
+	names, ok := relayServiceNames[mevType]
+	if !ok {
+		var supported []string
+		for t := range relayServiceNames {
+			supported = append(supported, t)
+		}
+		sort.Strings(supported)
+		return nil, stacktrace.NewError("relay targeting doesn't support mev_type '%s'. Supported types: %v", mevType, supported)
+	}
+	return names, nil
+}
+
+func createTargetSelectorForRelay(mevType string) (*ChaosTargetSelector, error) {
+	names, err := RelayServiceNames(mevType)
+	if err != nil {
+		return nil, err
+	}
+	selector := ChaosExpressionSelector{
+		Key:      "kurtosistech.com/id",
+		Operator: "In",
+		Values:   names,
+	}
+	return &ChaosTargetSelector{
+		Selector:    []ChaosExpressionSelector{selector},
+		Description: fmt.Sprintf("%s relay (%s)", mevType, strings.Join(names, ", ")),
+	}, nil
+}
+
 func TargetSpecEnumToLambda(targetSelector TargetingSpec, isExecClient bool) (func(networkNodeCount int, node *network.Node) *ChaosTargetSelector, error) {
 	if targetSelector == TargetMatchingNode {
 		return createTargetSelectorForNode, nil
@@ -186,53 +476,64 @@ func TargetSpecEnumToLambda(targetSelector TargetingSpec, isExecClient bool) (fu
 			return createTargetSelectorForConsensusClient, nil
 		}
 	}
+	if targetSelector == TargetMatchingMevBoost {
+		return createTargetSelectorForMevBoost, nil
+	}
 	return nil, stacktrace.NewError("target selector %s not supported", targetSelector)
 }
 
-func filterNodesByExecClient(elClientType string) TargetCriteriaFilter {
-	return func(size AttackSize, targetableSetSize int, nodes []*network.Node) ([]*network.Node, error) {
+func filterNodesByExecClient(elClientType string, selector *TargetSelector) TargetCriteriaFilter {
+	return func(size AttackSize, targetableSetSize, networkStake int, nodes []*network.Node) ([][]*network.Node, error) {
 		criteria := func(n *network.Node) bool {
-			return n.Execution.Type == elClientType
+			return network.MatchesClient(n.Execution.Type, n.Execution.Version, elClientType)
 		}
 		targetableNodes := filterNodes(nodes, criteria)
 		if targetableNodes == nil {
 			return nil, stacktrace.NewError("unable to satisfy targeting constraint")
 		}
-		return chooseTargetsUsingAttackSize(size, targetableSetSize, targetableNodes)
+		return selector.SelectTargets(size, targetableSetSize, networkStake, targetableNodes)
 	}
 }
 
-func filterNodesByConsensusClient(clClientType string) TargetCriteriaFilter {
-	return func(size AttackSize, targetableSetSize int, nodes []*network.Node) ([]*network.Node, error) {
+func filterNodesByConsensusClient(clClientType string, selector *TargetSelector) TargetCriteriaFilter {
+	return func(size AttackSize, targetableSetSize, networkStake int, nodes []*network.Node) ([][]*network.Node, error) {
 		criteria := func(n *network.Node) bool {
-			return n.Consensus.Type == clClientType
+			return network.MatchesClient(n.Consensus.Type, n.Consensus.Version, clClientType)
 		}
 		targetableNodes := filterNodes(nodes, criteria)
 
-		return chooseTargetsUsingAttackSize(size, targetableSetSize, targetableNodes)
+		return selector.SelectTargets(size, targetableSetSize, networkStake, targetableNodes)
 	}
 }
 
-func filterNodesByClientCombo(elClientType, clClientType string) TargetCriteriaFilter {
-	return func(size AttackSize, targetableSetSize int, nodes []*network.Node) ([]*network.Node, error) {
+func filterNodesByClientCombo(elClientType, clClientType string, selector *TargetSelector) TargetCriteriaFilter {
+	return func(size AttackSize, targetableSetSize, networkStake int, nodes []*network.Node) ([][]*network.Node, error) {
 		criteria := func(n *network.Node) bool {
-			return n.Consensus.Type == clClientType && n.Execution.Type == elClientType
+			return network.MatchesClient(n.Consensus.Type, n.Consensus.Version, clClientType) && network.MatchesClient(n.Execution.Type, n.Execution.Version, elClientType)
 		}
 		targetableNodes := filterNodes(nodes, criteria)
 
-		return chooseTargetsUsingAttackSize(size, targetableSetSize, targetableNodes)
+		return selector.SelectTargets(size, targetableSetSize, networkStake, targetableNodes)
 	}
 }
 
-func BuildChaosMeshTargetSelectors(networkNodeCount int, nodes []*network.Node, size AttackSize, targetCriteria TargetCriteriaFilter, impactSelector NodeImpactSelector) ([]*ChaosTargetSelector, error) {
-	targets, err := targetCriteria(size, len(nodes)+1, nodes)
+func BuildChaosMeshTargetSelectors(networkNodeCount, networkStake int, nodes []*network.Node, size AttackSize, targetCriteria TargetCriteriaFilter, impactSelector NodeImpactSelector) ([][]*ChaosTargetSelector, error) {
+	targetSets, err := targetCriteria(size, networkNodeCount, networkStake, nodes)
 	if err != nil {
 		return nil, err
 	}
 
+	targetSelectorSets := make([][]*ChaosTargetSelector, len(targetSets))
+	for i, targets := range targetSets {
+		targetSelectorSets[i] = buildTargetSelectors(networkNodeCount, targets, impactSelector)
+	}
+	return targetSelectorSets, nil
+}
+
+func buildTargetSelectors(networkNodeCount int, targets []*network.Node, impactSelector NodeImpactSelector) []*ChaosTargetSelector {
 	var targetSelectors []*ChaosTargetSelector
 	for _, node := range targets {
 		targetSelectors = append(targetSelectors, impactSelector(networkNodeCount, node))
 	}
-	return targetSelectors, nil
+	return targetSelectors
 }
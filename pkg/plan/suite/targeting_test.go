@@ -13,6 +13,14 @@ func NewMockNetworkUnconfigured(nodeCount int) []*network.Node {
 	return nodes
 }
 
+func NewMockNetworkWithStake(votes ...int) []*network.Node {
+	nodes := make([]*network.Node, len(votes))
+	for i, v := range votes {
+		nodes[i] = &network.Node{Index: i + 2, ConsensusVotes: v}
+	}
+	return nodes
+}
+
 func TestChooseTargetsUsingAttackSize(t *testing.T) {
 	type testCase struct {
 		NetworkSize           int
@@ -99,7 +107,7 @@ func TestChooseTargetsUsingAttackSize(t *testing.T) {
 
 	for i, test := range testCases {
 		nodes := NewMockNetworkUnconfigured(test.TargetableCount)
-		targets, err := chooseTargetsUsingAttackSize(test.AttackSize, test.NetworkSize, nodes)
+		targets, err := chooseTargetsUsingAttackSize(test.AttackSize, StakeFractionExact, test.NetworkSize, 0, nodes)
 
 		if err != nil {
 			if !test.ExpectConstraintError {
@@ -114,3 +122,190 @@ func TestChooseTargetsUsingAttackSize(t *testing.T) {
 
 	}
 }
+
+func TestChooseTargetsUsingStakeFraction(t *testing.T) {
+	type testCase struct {
+		NetworkStake          int
+		TargetableVotes       []int
+		AttackSize            AttackSize
+		Policy                StakeFractionPolicy
+		ExpectedStake         int
+		ExpectError           bool
+		ExpectConstraintError bool
+	}
+
+	testCases := []testCase{
+		// uneven stake, 1/2 is reachable exactly using 64+32+32
+		{
+			NetworkStake:    256,
+			TargetableVotes: []int{64, 32, 32, 16, 16},
+			AttackSize:      "stake:0.5",
+			Policy:          StakeFractionExact,
+			ExpectedStake:   128,
+		},
+		{
+			NetworkStake:    256,
+			TargetableVotes: []int{64, 32, 32, 16, 16},
+			AttackSize:      "stake:0.25",
+			Policy:          StakeFractionExact,
+			ExpectedStake:   64,
+		},
+		// 0.34 of 256 is 87.04, the closest reachable sum is 80
+		{
+			NetworkStake:    256,
+			TargetableVotes: []int{64, 32, 32, 16, 16},
+			AttackSize:      "stake:0.34",
+			Policy:          StakeFractionExact,
+			ExpectedStake:   -1,
+			ExpectError:     true,
+		},
+		{
+			NetworkStake:    256,
+			TargetableVotes: []int{64, 32, 32, 16, 16},
+			AttackSize:      "stake:0.34",
+			Policy:          StakeFractionClosest,
+			ExpectedStake:   80,
+		},
+		// nodes without stake can't contribute
+		{
+			NetworkStake:          128,
+			TargetableVotes:       []int{0, 0},
+			AttackSize:            "stake:0.5",
+			Policy:                StakeFractionClosest,
+			ExpectedStake:         -1,
+			ExpectConstraintError: true,
+		},
+		// malformed fractions
+		{
+			NetworkStake:    128,
+			TargetableVotes: []int{64},
+			AttackSize:      "stake:1.5",
+			Policy:          StakeFractionClosest,
+			ExpectedStake:   -1,
+			ExpectError:     true,
+		},
+	}
+
+	for i, test := range testCases {
+		nodes := NewMockNetworkWithStake(test.TargetableVotes...)
+		targets, err := chooseTargetsUsingAttackSize(test.AttackSize, test.Policy, len(nodes)+1, test.NetworkStake, nodes)
+
+		if err != nil {
+			_, isConstraintErr := err.(CannotMeetConstraintError)
+			if isConstraintErr && test.ExpectConstraintError {
+				continue
+			}
+			if !isConstraintErr && test.ExpectError {
+				continue
+			}
+			t.Fatalf("Fail case %d, unexpected err %s, case %v", i, err, test)
+		}
+		if test.ExpectError || test.ExpectConstraintError {
+			t.Fatalf("Fail case %d, expected an error, case %v", i, test)
+		}
+		stake := network.CountConsensusVotes(targets)
+		if stake != test.ExpectedStake {
+			t.Fatalf("Fail case %d, expected %d stake targeted, received %d. case %v", i, test.ExpectedStake, stake, test)
+		}
+	}
+}
+
+func TestTargetSelectorModes(t *testing.T) {
+	nodes := NewMockNetworkWithStake(32, 32, 32, 32, 32)
+
+	first := NewTargetSelector(PlannerFaultConfiguration{})
+	sets, err := first.SelectTargets(AttackOne, 6, 192, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 1 || sets[0][0] != nodes[0] {
+		t.Fatalf("first selection should target the first node, received %v", sets)
+	}
+
+	// round robin should move on to the next nodes with each selection
+	roundRobin := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectRoundRobin})
+	for i := 0; i < len(nodes)+1; i++ {
+		sets, err = roundRobin.SelectTargets(AttackOne, 6, 192, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sets[0][0] != nodes[i%len(nodes)] {
+			t.Fatalf("round robin selection %d targeted node #%d", i, sets[0][0].Index)
+		}
+	}
+
+	// the same seed should always produce the same targets
+	a := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectRandom, TargetSelectionSeed: 42})
+	b := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectRandom, TargetSelectionSeed: 42})
+	for i := 0; i < 5; i++ {
+		setsA, err := a.SelectTargets(AttackMinority, 6, 192, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setsB, err := b.SelectTargets(AttackMinority, 6, 192, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range setsA[0] {
+			if setsA[0][j] != setsB[0][j] {
+				t.Fatalf("random selection %d was not reproducible", i)
+			}
+		}
+	}
+
+	// 2 of 5 nodes produces 10 combinations
+	all := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectAllCombinations})
+	sets, err = all.SelectTargets("stake:0.3333333333333333", 6, 192, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 10 {
+		t.Fatalf("expected 10 combinations, received %d", len(sets))
+	}
+
+	capped := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectAllCombinations, MaxTargetCombinations: 4})
+	sets, err = capped.SelectTargets(AttackMinority, 6, 192, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 4 {
+		t.Fatalf("expected combinations to be capped at 4, received %d", len(sets))
+	}
+
+	// a cap equal to the number of combinations keeps every one of them
+	exact := NewTargetSelector(PlannerFaultConfiguration{TargetSelection: TargetSelectAllCombinations, MaxTargetCombinations: 10})
+	sets, err = exact.SelectTargets("stake:0.3333333333333333", 6, 192, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 10 {
+		t.Fatalf("expected all 10 combinations, received %d", len(sets))
+	}
+}
+
+func TestFilterNodesByClientVersion(t *testing.T) {
+	nodes := NewMockNetworkWithStake(32, 32, 32)
+	versions := []string{"v1.13.14", "v1.14.0", "v1.14.0"}
+	for i, node := range nodes {
+		node.Execution = &network.ExecutionClient{Type: "geth", Version: versions[i]}
+	}
+
+	filter := BuildNodeFilteringLambda("geth@v1.14.0", true, NewTargetSelector(PlannerFaultConfiguration{}))
+	sets, err := filter(AttackAll, 4, 128, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets[0]) != 2 || sets[0][0] != nodes[1] || sets[0][1] != nodes[2] {
+		t.Fatalf("expected only the v1.14.0 nodes to be targeted, received %v", sets[0])
+	}
+
+	// targets without a version match every version
+	filter = BuildNodeFilteringLambda("geth", true, NewTargetSelector(PlannerFaultConfiguration{}))
+	sets, err = filter(AttackAll, 4, 128, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets[0]) != 3 {
+		t.Fatalf("expected all geth nodes to be targeted, received %v", sets[0])
+	}
+}
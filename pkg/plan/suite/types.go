@@ -1,22 +1,31 @@
 package suite
 
-import "time"
+import (
+	"gopkg.in/yaml.v3"
+	"time"
+)
 
 type TargetingSpec string
 
 const (
-	TargetMatchingNode   TargetingSpec = "MatchingNode"
-	TargetMatchingClient TargetingSpec = "MatchingClient"
+	TargetMatchingNode     TargetingSpec = "MatchingNode"
+	TargetMatchingClient   TargetingSpec = "MatchingClient"
+	TargetMatchingMevBoost TargetingSpec = "MatchingMevBoost" // requires network_features.mev_type
+	TargetMatchingRelay    TargetingSpec = "MatchingRelay"    // requires network_features.mev_type
 )
 
 var TargetingSpecs = map[TargetingSpec]bool{
-	TargetMatchingNode:   true,
-	TargetMatchingClient: true,
+	TargetMatchingNode:     true,
+	TargetMatchingClient:   true,
+	TargetMatchingMevBoost: true,
+	TargetMatchingRelay:    true,
 }
 
 var TargetingSpecList = []TargetingSpec{
 	TargetMatchingNode,
 	TargetMatchingClient,
+	TargetMatchingMevBoost,
+	TargetMatchingRelay,
 }
 
 type AttackSize string
@@ -28,10 +37,26 @@ const (
 	AttackSuperminority AttackSize = "AttackSuperminorityMatching" // scope will be 33.333 < x < 50
 	AttackMajority      AttackSize = "AttackMajorityMatching"      // scope will be 50 < x < 66.6
 	AttackSupermajority AttackSize = "AttackSupermajorityMatching" // scope will be 66.66 < x < 100
-	// if we want to add 33.33%, 66.66%, and 50%, there needs to be logic to verify the network can be split into such
-	// fractions, otherwise using the number in target selection may be misleading.
 )
 
+// AttackSizeStakePrefix marks an attack size expressed as an exact fraction of the network's validator keys, e.g.
+// stake:0.34. Whether the fraction can be hit depends on how validator keys are distributed across the topology.
+const AttackSizeStakePrefix = "stake:"
+
+type StakeFractionPolicy string
+
+const (
+	// StakeFractionExact rejects stake attack sizes that can't be hit exactly by any subset of the matching targets.
+	StakeFractionExact StakeFractionPolicy = "exact"
+	// StakeFractionClosest uses the closest achievable fraction and logs the difference.
+	StakeFractionClosest StakeFractionPolicy = "closest"
+)
+
+var StakeFractionPolicies = map[StakeFractionPolicy]bool{
+	StakeFractionExact:   true,
+	StakeFractionClosest: true,
+}
+
 var AttackSizes = map[AttackSize]bool{
 	AttackOne:           true,
 	AttackAll:           true,
@@ -50,6 +75,31 @@ var AttackSizesList = []AttackSize{
 	AttackSupermajority,
 }
 
+type TargetSelectionMode string
+
+const (
+	TargetSelectFirst           TargetSelectionMode = "first"
+	TargetSelectRandom          TargetSelectionMode = "random"
+	TargetSelectRoundRobin      TargetSelectionMode = "round_robin"
+	TargetSelectAllCombinations TargetSelectionMode = "all_combinations"
+)
+
+var TargetSelectionModes = map[TargetSelectionMode]bool{
+	TargetSelectFirst:           true,
+	TargetSelectRandom:          true,
+	TargetSelectRoundRobin:      true,
+	TargetSelectAllCombinations: true,
+}
+
+var TargetSelectionModesList = []TargetSelectionMode{
+	TargetSelectFirst,
+	TargetSelectRandom,
+	TargetSelectRoundRobin,
+	TargetSelectAllCombinations,
+}
+
+const defaultMaxTargetCombinations = 16
+
 type FaultTypeEnum string
 
 const (
@@ -60,26 +110,18 @@ const (
 	FaultPacketLoss       FaultTypeEnum = "PacketLoss"
 )
 
-var FaultTypes = map[FaultTypeEnum]bool{
-	FaultClockSkew:        true,
-	FaultContainerRestart: true,
-	FaultIOLatency:        true,
-	FaultNetworkLatency:   true,
-}
-
-var FaultTypesList = []FaultTypeEnum{
-	FaultClockSkew,
-	FaultContainerRestart,
-	FaultIOLatency,
-	FaultNetworkLatency,
-	FaultPacketLoss,
-}
-
 type PlannerFaultConfiguration struct {
 	FaultType             FaultTypeEnum       `yaml:"fault_type"`
 	TargetClient          string              `yaml:"target_client"`
 	WaitBeforeFirstTest   time.Duration       `yaml:"wait_before_first_test"`
-	FaultConfigDimensions []map[string]string `yaml:"fault_config_dimensions"`
+	FaultConfigDimensions []yaml.Node         `yaml:"fault_config_dimensions"`
 	TargetingDimensions   []TargetingSpec     `yaml:"fault_targeting_dimensions"`
 	AttackSizeDimensions  []AttackSize        `yaml:"fault_attack_size_dimensions"`
+	StakeFractionPolicy   StakeFractionPolicy `yaml:"stake_fraction_policy,omitempty"`
+	TargetSelection       TargetSelectionMode `yaml:"target_selection,omitempty"`
+	TargetSelectionSeed   int64               `yaml:"target_selection_seed,omitempty"`
+	MaxTargetCombinations int                 `yaml:"max_target_combinations,omitempty"`
+
+	// FaultConfigDimensions decoded using the fault type. Populated by DecodeDimensions.
+	Dimensions []FaultDimension `yaml:"-"`
 }
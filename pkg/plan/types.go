@@ -13,11 +13,28 @@ type PlannerConfig struct {
 	KurtosisPackage     string                          `yaml:"kurtosis_package"`
 	KubernetesNamespace string                          `yaml:"kubernetes_namespace"`
 	FaultConfig         suite.PlannerFaultConfiguration `yaml:"fault_config"`
+	NetworkFeatures     NetworkFeatures                 `yaml:"network_features"`
+	Estimate            EstimateConfig                  `yaml:"estimate"`
+}
+
+// NetworkFeatures controls the network-wide options passed through to the ethereum-package.
+type NetworkFeatures struct {
+	AdditionalServices  *[]string              `yaml:"additional_services,omitempty"`
+	DisablePeerScoring  *bool                  `yaml:"disable_peer_scoring,omitempty"`
+	Persistent          bool                   `yaml:"persistent"`
+	ParallelKeystoreGen bool                   `yaml:"parallel_keystore_generation"`
+	MevType             string                 `yaml:"mev_type,omitempty"`
+	MevParams           map[string]interface{} `yaml:"mev_params,omitempty"`
+}
+
+var defaultAdditionalServices = []string{
+	"prometheus_grafana",
+	"dora",
 }
 
 func (c *PlannerConfig) IsTargetExecutionClient() bool {
 	for _, execClient := range c.ExecutionClients {
-		if execClient.Name == c.FaultConfig.TargetClient {
+		if network.MatchesClient(execClient.Name, execClient.Version, c.FaultConfig.TargetClient) {
 			return true
 		}
 	}
@@ -26,7 +43,7 @@ func (c *PlannerConfig) IsTargetExecutionClient() bool {
 
 func (c *PlannerConfig) IsTargetConsensusClient() bool {
 	for _, consClient := range c.ConsensusClients {
-		if consClient.Name == c.FaultConfig.TargetClient {
+		if network.MatchesClient(consClient.Name, consClient.Version, c.FaultConfig.TargetClient) {
 			return true
 		}
 	}
@@ -34,12 +51,14 @@ func (c *PlannerConfig) IsTargetConsensusClient() bool {
 }
 
 type EthKurtosisConfig struct {
-	Participants        []*Participant        `yaml:"participants"`
-	NetParams           network.GenesisConfig `yaml:"network_params"`
-	AdditionalServices  []string              `yaml:"additional_services"`
-	ParallelKeystoreGen bool                  `yaml:"parallel_keystore_generation"`
-	Persistent          bool                  `yaml:"persistent"`
-	DisablePeerScoring  bool                  `yaml:"disable_peer_scoring"`
+	Participants        []*Participant         `yaml:"participants"`
+	NetParams           network.GenesisConfig  `yaml:"network_params"`
+	AdditionalServices  []string               `yaml:"additional_services"`
+	ParallelKeystoreGen bool                   `yaml:"parallel_keystore_generation"`
+	Persistent          bool                   `yaml:"persistent"`
+	DisablePeerScoring  bool                   `yaml:"disable_peer_scoring"`
+	MevType             string                 `yaml:"mev_type,omitempty"`
+	MevParams           map[string]interface{} `yaml:"mev_params,omitempty"`
 }
 
 type Participant struct {
@@ -49,6 +68,17 @@ type Participant struct {
 	ClClientType  string `yaml:"cl_type"`
 	ClClientImage string `yaml:"cl_image"`
 
+	ElExtraLabels map[string]string `yaml:"el_extra_labels,omitempty"`
+	ClExtraLabels map[string]string `yaml:"cl_extra_labels,omitempty"`
+	VcExtraLabels map[string]string `yaml:"vc_extra_labels,omitempty"`
+
+	ValidatorCount *int  `yaml:"validator_count,omitempty"`
+	UseSeparateVc  *bool `yaml:"use_separate_vc,omitempty"`
+
+	ElExtraParams []string `yaml:"el_extra_params,omitempty"`
+	ClExtraParams []string `yaml:"cl_extra_params,omitempty"`
+	VcExtraParams []string `yaml:"vc_extra_params,omitempty"`
+
 	ElMinCpu    int `yaml:"el_min_cpu"`
 	ElMaxCpu    int `yaml:"el_max_cpu"`
 	ElMinMemory int `yaml:"el_min_mem"`
@@ -64,5 +94,8 @@ type Participant struct {
 	ValMinMemory int `yaml:"vc_min_mem,omitempty"`
 	ValMaxMemory int `yaml:"vc_max_mem,omitempty"`
 
+	ElVolumeSize int `yaml:"el_volume_size,omitempty"`
+	ClVolumeSize int `yaml:"cl_volume_size,omitempty"`
+
 	Count int `yaml:"count"`
 }
@@ -16,6 +16,13 @@ func defaultConfig() *types.Config {
 			ExistingDevnetNamespace:    "",
 			ReuseDevnetBetweenRuns:     true,
 			AllowPostFaultInspection:   true,
+			RpcTimeoutSeconds:          10,
+			AccessMode:                 types.PortForward,
+			PrometheusPodName:          "prometheus",
+			PrometheusPodPort:          "9090",
+		},
+		HarnessConfig: types.HarnessConfig{
+			ClientSchema: types.DefaultClientSchema(),
 		},
 	}
 	return &cfg
@@ -50,6 +57,37 @@ func loadSuiteFromPath(path string) (*types.ConfigParsed, error) {
 		return nil, stacktrace.Propagate(err, "Could not unmarshal the suite definition file")
 	}
 
+	if !types.AccessModes[cfg.AttacknetConfig.AccessMode] {
+		return nil, stacktrace.NewError("unknown access mode '%s'. Supported modes: %s, %s, %s", cfg.AttacknetConfig.AccessMode, types.PortForward, types.PodIp, types.ServiceDns)
+	}
+
+	for _, test := range cfg.TestConfig.Tests {
+		if test.HealthConfig.Mode != "" && !types.HealthCheckModes[test.HealthConfig.Mode] {
+			return nil, stacktrace.NewError("test %s has an unknown health check mode '%s'. Supported modes: %s, %s", test.TestName, test.HealthConfig.Mode, types.NetworkRecovery, types.NodeRecovery)
+		}
+		for _, check := range test.HealthConfig.PrometheusChecks {
+			if check.Name == "" || check.Query == "" {
+				return nil, stacktrace.NewError("test %s has a prometheus check without a name or query", test.TestName)
+			}
+			if check.Operator != "" && !types.PrometheusOperators[check.Operator] {
+				return nil, stacktrace.NewError("prometheus check %s of test %s has an unknown operator '%s'", check.Name, test.TestName, check.Operator)
+			}
+			if (check.Operator == "") != (check.Threshold == nil) {
+				return nil, stacktrace.NewError("prometheus check %s of test %s needs both an operator and a threshold, or neither", check.Name, test.TestName)
+			}
+		}
+		if checks := test.HealthConfig.ValidatorChecks; checks != nil {
+			for _, rate := range []float64{checks.MinProposerHitRate, checks.MinParticipationRate, checks.MinValidatorEffectiveness} {
+				if rate < 0 || rate > 1 {
+					return nil, stacktrace.NewError("test %s has a validator check threshold of %f. thresholds must be between 0 and 1", test.TestName, rate)
+				}
+			}
+			if checks.ProposalWindowSlots < 0 {
+				return nil, stacktrace.NewError("test %s has a negative proposalWindowSlots", test.TestName)
+			}
+		}
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "Could not get working directory")
@@ -67,6 +105,7 @@ func loadSuiteFromPath(path string) (*types.ConfigParsed, error) {
 			NetworkType:    cfg.HarnessConfig.NetworkType,
 			NetworkPackage: cfg.HarnessConfig.NetworkPackage,
 			NetworkConfig:  packageConfig,
+			ClientSchema:   cfg.HarnessConfig.ClientSchema,
 		},
 		TestConfig: cfg.TestConfig,
 	}
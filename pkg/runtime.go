@@ -4,7 +4,9 @@ import (
 	"attacknet/cmd/pkg/artifacts"
 	chaos_mesh "attacknet/cmd/pkg/chaos-mesh"
 	"attacknet/cmd/pkg/health"
+	"attacknet/cmd/pkg/health/ethereum"
 	"attacknet/cmd/pkg/kubernetes"
+	"attacknet/cmd/pkg/plan/network"
 	"attacknet/cmd/pkg/runtime"
 	"attacknet/cmd/pkg/test_executor"
 	"attacknet/cmd/pkg/types"
@@ -14,6 +16,7 @@ import (
 )
 
 func StartTestSuite(ctx context.Context, cfg *types.ConfigParsed) error {
+	runStart := time.Now()
 	enclave, err := runtime.SetupEnclave(ctx, cfg)
 	if err != nil {
 		return err
@@ -24,11 +27,18 @@ func StartTestSuite(ctx context.Context, cfg *types.ConfigParsed) error {
 		return err
 	}
 
+	// annotations are optional, so a network without grafana can still be tested
+	grafanaTunnel, err := CreateGrafanaClient(ctx, kubeClient, cfg.AttacknetConfig)
+	if err != nil {
+		log.Warnf("Unable to connect to grafana. Continuing without annotations: %s", err)
+		grafanaTunnel = nil
+	}
+	defer grafanaTunnel.Cleanup(true)
+
 	// create chaos-mesh client
 	log.Infof("Creating a chaos-mesh client")
 	chaosClient, err := chaos_mesh.CreateClient(enclave.Namespace, kubeClient)
 	if err != nil {
-		//	grafanaTunnel.Cleanup(true)
 		return err
 	}
 
@@ -39,45 +49,113 @@ func StartTestSuite(ctx context.Context, cfg *types.ConfigParsed) error {
 	)
 	time.Sleep(time.Duration(cfg.AttacknetConfig.WaitBeforeInjectionSeconds) * time.Second)
 
+	validatorKeys, err := network.ValidatorKeysByNodeIndex(cfg.HarnessConfig.NetworkConfig)
+	if err != nil {
+		log.Warnf("Unable to determine validator keys per node from the network config. Artifacts won't include validator key counts: %s", err)
+		validatorKeys = nil
+	}
+
+	// shared by the health checks of every test so port-forwards aren't reopened each round
+	rpcPool := ethereum.CreateRpcPool(kubeClient, cfg.HarnessConfig.ClientSchema, cfg.AttacknetConfig.AccessMode, time.Duration(cfg.AttacknetConfig.RpcTimeoutSeconds)*time.Second)
+	defer rpcPool.Close()
+	prometheus, err := health.CreatePrometheusClient(kubeClient, cfg.AttacknetConfig)
+	if err != nil {
+		return err
+	}
+	defer prometheus.Close()
+
 	log.Infof("Running %d tests", len(cfg.TestConfig.Tests))
 
 	var testArtifacts []*artifacts.TestArtifact
 
+	// a nil *GrafanaTunnel would be a non-nil FaultObserver, so only pass the tunnel when grafana is available
+	var observer test_executor.FaultObserver
+	if grafanaTunnel != nil {
+		observer = grafanaTunnel
+	}
+
 	for i, test := range cfg.TestConfig.Tests {
 		log.Infof("Running test (%d/%d): '%s'", i+1, len(cfg.TestConfig.Tests), test.TestName)
-		executor := test_executor.CreateTestExecutor(chaosClient, test)
+		executor := test_executor.CreateTestExecutor(chaosClient, test, observer)
 
-		err = executor.RunTestPlan(ctx)
-		if err != nil {
-			log.Errorf("Error while running test #%d", i+1)
-			return err
-		} else {
-			log.Infof("Test #%d steps completed.", i+1)
-		}
+		// runs the test in a closure so its faults are removed on every exit path
+		testPassed, err := func() (bool, error) {
+			defer func() {
+				err := executor.RemoveFaults(ctx)
+				if err != nil {
+					log.Warnf("Unable to remove the faults of test '%s': %s", test.TestName, err)
+				}
+			}()
+
+			testStart := time.Now()
+			podStatusBefore, err := health.TakePodStatusSnapshot(ctx, kubeClient)
+			if err != nil {
+				return false, err
+			}
+
+			err = executor.RunTestPlan(ctx)
+			if err != nil {
+				log.Errorf("Error while running test #%d", i+1)
+				return false, err
+			} else {
+				log.Infof("Test #%d steps completed.", i+1)
+			}
+
+			if !test.HealthConfig.EnableChecks {
+				log.Info("Skipping health checks")
+				return true, nil
+			}
 
-		if test.HealthConfig.EnableChecks {
 			log.Info("Starting health checks")
 			podsUnderTest, err := executor.GetPodsUnderTest()
 			if err != nil {
-				return err
+				return false, err
+			}
+			var podNames []string
+			for _, pod := range podsUnderTest {
+				podNames = append(podNames, pod.Name)
 			}
+			grafanaTunnel.Annotate(ctx, "health-checks-started", test.TestName, podNames, "Health checks started")
 
-			hc, err := health.BuildHealthChecker(kubeClient, podsUnderTest, test.HealthConfig)
+			hc, err := health.BuildHealthChecker(kubeClient, rpcPool, prometheus, podsUnderTest, test.HealthConfig, cfg.HarnessConfig.ClientSchema, validatorKeys)
 			if err != nil {
-				return err
+				return false, err
 			}
 			results, err := hc.RunChecks(ctx)
 			if err != nil {
-				return err
+				return false, err
 			}
-			testArtifact := artifacts.BuildTestArtifact(results, podsUnderTest, test)
+			results.PodStatusResult, err = health.ComparePodStatus(ctx, kubeClient, podStatusBefore, podsUnderTest, cfg.HarnessConfig.ClientSchema.ServiceIdLabel)
+			if err != nil {
+				return false, err
+			}
+			testArtifact := artifacts.BuildTestArtifact(results, podsUnderTest, test, validatorKeys)
 			testArtifacts = append(testArtifacts, testArtifact)
-			if !testArtifact.TestPassed {
-				log.Warn("Some health checks failed. Stopping test suite.")
-				break
+			if testArtifact.TestPassed {
+				grafanaTunnel.Annotate(ctx, "health-checks-passed", test.TestName, podNames, "Health checks passed")
+				return true, nil
+			}
+
+			grafanaTunnel.Annotate(ctx, "health-checks-failed", test.TestName, podNames, "Health checks failed")
+			if grafanaTunnel != nil {
+				snapshotDir, err := artifacts.SnapshotDirectory(runStart)
+				if err != nil {
+					log.Warnf("Unable to capture grafana dashboards for test '%s': %s", test.TestName, err)
+				} else {
+					testArtifact.DashboardSnapshots, err = grafanaTunnel.SnapshotDashboards(ctx, test.TestName, testStart, time.Now(), snapshotDir)
+					if err != nil {
+						log.Warnf("Unable to capture grafana dashboards for test '%s': %s", test.TestName, err)
+					}
+				}
 			}
-		} else {
-			log.Info("Skipping health checks")
+			return false, nil
+		}()
+		if err != nil {
+			return err
+		}
+		if !testPassed {
+			log.Warn("Some health checks failed. Stopping test suite.")
+			break
 		}
 	}
 	err = artifacts.SerializeTestArtifacts(testArtifacts)
@@ -85,6 +163,8 @@ func StartTestSuite(ctx context.Context, cfg *types.ConfigParsed) error {
 		return err
 	}
 
+	// the enclave is about to be destroyed, so this is the last chance to look at grafana
+	grafanaTunnel.Cleanup(false)
 	enclave.Destroy(ctx)
 
 	return nil
@@ -11,16 +11,34 @@ import (
 	"time"
 )
 
+// FaultObserver is notified as the faults of a test are injected, recover and are removed.
+type FaultObserver interface {
+	FaultInjected(ctx context.Context, testName string, session *chaos_mesh.FaultSession)
+	FaultRecovered(ctx context.Context, testName string, session *chaos_mesh.FaultSession)
+	FaultRemoved(ctx context.Context, testName string, session *chaos_mesh.FaultSession)
+}
+
+// noopObserver is used when the caller doesn't need fault notifications.
+type noopObserver struct{}
+
+func (noopObserver) FaultInjected(context.Context, string, *chaos_mesh.FaultSession)  {}
+func (noopObserver) FaultRecovered(context.Context, string, *chaos_mesh.FaultSession) {}
+func (noopObserver) FaultRemoved(context.Context, string, *chaos_mesh.FaultSession)   {}
+
 type TestExecutor struct {
 	chaosClient   *chaos_mesh.ChaosClient
+	observer      FaultObserver
 	testName      string
 	planSteps     []types.PlanStep
 	faultSessions []*chaos_mesh.FaultSession
 	planCompleted bool
 }
 
-func CreateTestExecutor(chaosClient *chaos_mesh.ChaosClient, test types.SuiteTest) *TestExecutor {
-	return &TestExecutor{chaosClient: chaosClient, testName: test.TestName, planSteps: test.PlanSteps}
+func CreateTestExecutor(chaosClient *chaos_mesh.ChaosClient, test types.SuiteTest, observer FaultObserver) *TestExecutor {
+	if observer == nil {
+		observer = noopObserver{}
+	}
+	return &TestExecutor{chaosClient: chaosClient, observer: observer, testName: test.TestName, planSteps: test.PlanSteps}
 }
 
 func (te *TestExecutor) RunTestPlan(ctx context.Context) error {
@@ -106,7 +124,11 @@ func (te *TestExecutor) runInjectFaultStep(ctx context.Context, step PlanStepSin
 	te.faultSessions = append(te.faultSessions, faultSession)
 
 	err = waitForInjectionCompleted(ctx, faultSession)
-	return err
+	if err != nil {
+		return err
+	}
+	te.observer.FaultInjected(ctx, te.testName, faultSession)
+	return nil
 }
 
 func (te *TestExecutor) runWaitForFaultCompletion(ctx context.Context, _ PlanStepWaitForFaultCompletion) error {
@@ -128,11 +150,24 @@ func (te *TestExecutor) runWaitForFaultCompletion(ctx context.Context, _ PlanSte
 		if err != nil {
 			return err
 		}
+		te.observer.FaultRecovered(ctx, te.testName, fs)
 		log.Infof("Fault #%d has completed", i+1)
 	}
 	return nil
 }
 
+// RemoveFaults deletes the fault resources of the test so they don't linger in a reused devnet.
+func (te *TestExecutor) RemoveFaults(ctx context.Context) error {
+	for _, fs := range te.faultSessions {
+		err := fs.Remove(ctx)
+		if err != nil {
+			return err
+		}
+		te.observer.FaultRemoved(ctx, te.testName, fs)
+	}
+	return nil
+}
+
 func (te *TestExecutor) runWaitForDuration(step PlanStepWait) error {
 	log.Infof("Sleeping for %.0f seconds", step.WaitAmount.Seconds())
 	time.Sleep(step.WaitAmount)
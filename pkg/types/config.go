@@ -3,33 +3,145 @@ package types
 import "time"
 
 type AttacknetConfig struct {
-	GrafanaPodName             string `yaml:"grafanaPodName"`
-	GrafanaPodPort             string `yaml:"grafanaPodPort"`
-	AllowPostFaultInspection   bool   `yaml:"allowPostFaultInspection"`
-	WaitBeforeInjectionSeconds uint32 `yaml:"waitBeforeInjectionSeconds"`
-	ReuseDevnetBetweenRuns     bool   `yaml:"reuseDevnetBetweenRuns"`
-	ExistingDevnetNamespace    string `yaml:"existingDevnetNamespace"`
+	GrafanaPodName             string     `yaml:"grafanaPodName"`
+	GrafanaPodPort             string     `yaml:"grafanaPodPort"`
+	AllowPostFaultInspection   bool       `yaml:"allowPostFaultInspection"`
+	WaitBeforeInjectionSeconds uint32     `yaml:"waitBeforeInjectionSeconds"`
+	ReuseDevnetBetweenRuns     bool       `yaml:"reuseDevnetBetweenRuns"`
+	ExistingDevnetNamespace    string     `yaml:"existingDevnetNamespace"`
+	RpcTimeoutSeconds          uint32     `yaml:"rpcTimeoutSeconds,omitempty"` // how long each health check RPC call may take
+	AccessMode                 AccessMode `yaml:"accessMode,omitempty"`        // how health checks reach the nodes. defaults to port-forward
+	PrometheusPodName          string     `yaml:"prometheusPodName,omitempty"`
+	PrometheusPodPort          string     `yaml:"prometheusPodPort,omitempty"`
+}
+
+type AccessMode string
+
+const (
+	// PortForward reaches nodes through port-forwards opened via the kubernetes API. Works from outside the cluster.
+	PortForward AccessMode = "port-forward"
+	// PodIp dials the IP of each pod directly. Requires attacknet to run inside the cluster.
+	PodIp AccessMode = "pod-ip"
+	// ServiceDns dials the service Kurtosis creates for each node. Requires attacknet to run inside the cluster.
+	ServiceDns AccessMode = "service-dns"
+)
+
+var AccessModes = map[AccessMode]bool{
+	PortForward: true,
+	PodIp:       true,
+	ServiceDns:  true,
 }
 
 type HarnessConfig struct {
-	NetworkType       string `yaml:"networkType"`
-	NetworkPackage    string `yaml:"networkPackage"`
-	NetworkConfigPath string `yaml:"networkConfig"`
+	NetworkType       string       `yaml:"networkType"`
+	NetworkPackage    string       `yaml:"networkPackage"`
+	NetworkConfigPath string       `yaml:"networkConfig"`
+	ClientSchema      ClientSchema `yaml:"clientSchema,omitempty"`
 }
 
 type HarnessConfigParsed struct {
 	NetworkType    string
 	NetworkPackage string
 	NetworkConfig  []byte
+	ClientSchema   ClientSchema
+}
+
+// ClientSchema describes how the pods of each client are labeled and which ports their APIs listen on.
+type ClientSchema struct {
+	ClientTypeLabel     string      `yaml:"clientTypeLabel,omitempty"`     // label identifying whether a pod is an execution or beacon client
+	ClientNameLabel     string      `yaml:"clientNameLabel,omitempty"`     // label holding the client implementation, e.g. prysm
+	ServiceIdLabel      string      `yaml:"serviceIdLabel,omitempty"`      // label that stays the same when a pod is recreated
+	ExecutionLabelValue string      `yaml:"executionLabelValue,omitempty"` // value of the client type label on execution clients
+	BeaconLabelValue    string      `yaml:"beaconLabelValue,omitempty"`    // value of the client type label on beacon clients
+	ExecutionRpcPort    ClientPorts `yaml:"executionRpcPort,omitempty"`
+	BeaconApiPort       ClientPorts `yaml:"beaconApiPort,omitempty"`
+}
+
+// ClientPorts resolves the port of an API. Per-client overrides take priority, then the named container port of the
+// pod, then the default.
+type ClientPorts struct {
+	Clients  map[string]int `yaml:"clients,omitempty"`  // ports keyed by client implementation
+	PortName string         `yaml:"portName,omitempty"` // name of the container port to use when the pod exposes it
+	Default  int            `yaml:"default,omitempty"`
+}
+
+func DefaultClientSchema() ClientSchema {
+	return ClientSchema{
+		ClientTypeLabel:     "kurtosistech.com.custom/ethereum-package.client-type",
+		ClientNameLabel:     "kurtosistech.com.custom/ethereum-package.client",
+		ServiceIdLabel:      "kurtosistech.com/id",
+		ExecutionLabelValue: "execution",
+		BeaconLabelValue:    "beacon",
+		ExecutionRpcPort: ClientPorts{
+			Clients:  map[string]int{},
+			PortName: "rpc",
+			Default:  8545,
+		},
+		BeaconApiPort: ClientPorts{
+			Clients:  map[string]int{"prysm": 3500},
+			PortName: "http",
+			Default:  4000,
+		},
+	}
 }
 
 type SuiteTestConfigs struct {
 	Tests []SuiteTest `yaml:"tests"`
 }
 
+type HealthCheckMode string
+
+const (
+	// NetworkRecovery health checks every pod except the ones the faults are expected to kill.
+	NetworkRecovery HealthCheckMode = "network_recovery"
+	// NodeRecovery also health checks the killed pods once they have restarted.
+	NodeRecovery HealthCheckMode = "node_recovery"
+)
+
+var HealthCheckModes = map[HealthCheckMode]bool{
+	NetworkRecovery: true,
+	NodeRecovery:    true,
+}
+
 type HealthCheckConfig struct {
-	EnableChecks bool           `yaml:"enableChecks"`
-	GracePeriod  *time.Duration `yaml:"gracePeriod"`
+	EnableChecks bool            `yaml:"enableChecks"`
+	GracePeriod  *time.Duration  `yaml:"gracePeriod"`
+	Mode         HealthCheckMode `yaml:"mode,omitempty"`         // defaults to network_recovery
+	MinPeers     *int            `yaml:"minPeers,omitempty"`     // peers every EL and CL client needs to pass. unset disables the peer and sync checks
+	AllowSyncing bool            `yaml:"allowSyncing,omitempty"` // whether clients may still be syncing when checks pass. only used with minPeers
+	// how many epochs the finalized checkpoint may trail the head by. unset disables the check
+	MaxFinalityLagEpochs *int `yaml:"maxFinalityLagEpochs,omitempty"`
+	// thresholds for proposals, attestations and validator effectiveness. unset disables the checks
+	ValidatorChecks *ValidatorCheckConfig `yaml:"validatorChecks,omitempty"`
+	// PromQL queries run against the enclave's prometheus on every round of health checks
+	PrometheusChecks []PrometheusCheck `yaml:"prometheusChecks,omitempty"`
+}
+
+// PrometheusCheck is a PromQL query every returned series has to satisfy. With an operator, each value is compared to
+// the threshold. Without one, the query is a boolean expression and each value has to be non-zero. A query that
+// returns no series fails.
+type PrometheusCheck struct {
+	Name      string   `yaml:"name"`
+	Query     string   `yaml:"query"`
+	Operator  string   `yaml:"operator,omitempty"` // one of >, >=, <, <=, ==, !=
+	Threshold *float64 `yaml:"threshold,omitempty"`
+}
+
+var PrometheusOperators = map[string]bool{
+	">":  true,
+	">=": true,
+	"<":  true,
+	"<=": true,
+	"==": true,
+	"!=": true,
+}
+
+// ValidatorCheckConfig sets the thresholds of the validator performance checks. Rates are between 0 and 1.
+type ValidatorCheckConfig struct {
+	ProposalWindowSlots       int     `yaml:"proposalWindowSlots,omitempty"` // how many recent slots the proposer hit rate covers. defaults to 32
+	MinProposerHitRate        float64 `yaml:"minProposerHitRate"`
+	MinParticipationRate      float64 `yaml:"minParticipationRate"`      // attestation participation of the previous epoch
+	MinValidatorEffectiveness float64 `yaml:"minValidatorEffectiveness"` // per node under test
 }
 
 type SuiteTest struct {